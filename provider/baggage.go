@@ -0,0 +1,105 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"sort"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// allBaggageKeys 复制所有Baggage
+const allBaggageKeys = "*"
+
+// baggageProcessor 在Span开始时把父Context中的Baggage复制为Span属性，
+// maxAttributes和maxValueLength限制复制的属性数和属性值长度，0表示不限制，
+// 属性按配置的key顺序复制，复制所有Baggage时按key排序，保证超出maxAttributes时保留的属性是确定的
+type baggageProcessor struct {
+	all            bool
+	keys           []string
+	prefix         string
+	maxAttributes  int
+	maxValueLength int
+}
+
+func newBaggageProcessor(keys []string, prefix string, maxAttributes, maxValueLength int) *baggageProcessor {
+	p := &baggageProcessor{prefix: prefix, maxAttributes: maxAttributes, maxValueLength: maxValueLength}
+	seen := map[string]struct{}{}
+	for _, key := range keys {
+		if key == allBaggageKeys {
+			p.all = true
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		p.keys = append(p.keys, key)
+	}
+	return p
+}
+
+// members 返回需要复制的Baggage成员
+func (p *baggageProcessor) members(b baggage.Baggage) []baggage.Member {
+	if p.all {
+		members := b.Members()
+		sort.Slice(members, func(i, j int) bool { return members[i].Key() < members[j].Key() })
+		return members
+	}
+	members := make([]baggage.Member, 0, len(p.keys))
+	for _, key := range p.keys {
+		if m := b.Member(key); m.Key() != "" {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func (p *baggageProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	b := baggage.FromContext(ctx)
+	if b.Len() == 0 {
+		return
+	}
+	members := p.members(b)
+	attrs := make([]attribute.KeyValue, 0, len(members))
+	for _, m := range members {
+		if p.maxAttributes > 0 && len(attrs) >= p.maxAttributes {
+			break
+		}
+		value := m.Value()
+		if p.maxValueLength > 0 && len(value) > p.maxValueLength {
+			value = truncateUTF8(value, p.maxValueLength)
+		}
+		attrs = append(attrs, attribute.String(p.prefix+m.Key(), value))
+	}
+	s.SetAttributes(attrs...)
+}
+
+// truncateUTF8 截断到不超过n字节，不拆分多字节字符
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func (p *baggageProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (p *baggageProcessor) Shutdown(context.Context) error { return nil }
+
+func (p *baggageProcessor) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,91 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func contextWithBaggage(t *testing.T, kv ...string) context.Context {
+	t.Helper()
+	var members []baggage.Member
+	for i := 0; i < len(kv); i += 2 {
+		m, err := baggage.NewMember(kv[i], kv[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		members = append(members, m)
+	}
+	b, err := baggage.New(members...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return baggage.ContextWithBaggage(context.Background(), b)
+}
+
+func baggageAttributes(t *testing.T, ctx context.Context) []attribute.KeyValue {
+	t.Helper()
+	exporter := &recordingSpanExporter{}
+	startTestTracer(t, exporter, WithBaggageAttributes("baggage.", "tenant", "user", "region"))
+	_, span := otel.Tracer("test").Start(ctx, "span")
+	span.End()
+	forceFlush(t)
+	spans := exporter.spans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	return spans[0].Attributes()
+}
+
+func TestBaggageAttributesFollowKeyOrder(t *testing.T) {
+	t.Setenv("SLS_OTEL_BAGGAGE_MAX_ATTRIBUTES", "2")
+	ctx := contextWithBaggage(t, "region", "cn-hangzhou", "user", "alice", "tenant", "acme", "other", "x")
+	// 多次运行结果相同，保留的总是按配置顺序排在前面的key
+	for i := 0; i < 20; i++ {
+		attrs := baggageAttributes(t, ctx)
+		if len(attrs) != 2 || attrs[0] != attribute.String("baggage.tenant", "acme") ||
+			attrs[1] != attribute.String("baggage.user", "alice") {
+			t.Fatalf("attributes = %v", attrs)
+		}
+	}
+}
+
+func TestBaggageAllKeysSorted(t *testing.T) {
+	p := newBaggageProcessor([]string{allBaggageKeys}, "", 0, 0)
+	ctx := contextWithBaggage(t, "c", "3", "a", "1", "b", "2")
+	members := p.members(baggage.FromContext(ctx))
+	var keys []string
+	for _, m := range members {
+		keys = append(keys, m.Key())
+	}
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Fatalf("keys = %v, want [a b c]", keys)
+	}
+}
+
+func TestBaggageReleaseChannelKept(t *testing.T) {
+	t.Setenv("SLS_OTEL_BAGGAGE_MAX_ATTRIBUTES", "1")
+	t.Setenv("SLS_OTEL_RELEASE_CHANNEL", ReleaseChannelStable)
+	ctx := contextWithBaggage(t, "tenant", "acme", string(ReleaseChannelKey), "canary")
+	attrs := baggageAttributes(t, ctx)
+	if len(attrs) != 1 || attrs[0] != attribute.String("baggage."+string(ReleaseChannelKey), "canary") {
+		t.Fatalf("attributes = %v", attrs)
+	}
+}
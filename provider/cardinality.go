@@ -0,0 +1,138 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const (
+	// SDK的基数限制只能通过该环境变量开启，见setSDKEnv
+	cardinalityLimitEnv = "OTEL_GO_X_CARDINALITY_LIMIT"
+	overflowAttribute   = attribute.Key("otel.metric.overflow")
+)
+
+// enableCardinalityLimit 开启SDK的基数限制，超出限制的属性组合会合并到otel.metric.overflow=true的序列中，
+// limit为0时清除provider之前设置的限制，用户已经设置OTEL_GO_X_CARDINALITY_LIMIT时不做修改，返回实际生效的限制
+func enableCardinalityLimit(limit int) int {
+	value := ""
+	if limit > 0 {
+		value = strconv.Itoa(limit)
+	}
+	if setSDKEnv(cardinalityLimitEnv, value) {
+		return limit
+	}
+	limit, _ = strconv.Atoi(os.Getenv(cardinalityLimitEnv))
+	return limit
+}
+
+// cardinalityTracker 根据导出数据中的overflow序列统计超出基数限制的指标
+type cardinalityTracker struct {
+	limit int
+
+	mu       sync.Mutex
+	reported map[string]struct{}
+	// 最近一次导出中超出限制的指标数
+	capped atomic.Int64
+}
+
+func newCardinalityTracker(limit int) *cardinalityTracker {
+	return &cardinalityTracker{limit: limit, reported: map[string]struct{}{}}
+}
+
+// observe 检查导出的数据，指标第一次超出限制时通过ErrorHandler报告
+func (t *cardinalityTracker) observe(rm *metricdata.ResourceMetrics) {
+	var capped int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if !hasOverflow(m.Data) {
+				continue
+			}
+			capped++
+			name := sm.Scope.Name + "/" + m.Name
+			t.mu.Lock()
+			_, ok := t.reported[name]
+			t.reported[name] = struct{}{}
+			t.mu.Unlock()
+			if !ok {
+				otel.Handle(fmt.Errorf("metric %s from %s exceeded the cardinality limit %d, new attribute sets are recorded as %s=true",
+					m.Name, sm.Scope.Name, t.limit, overflowAttribute))
+			}
+		}
+	}
+	t.capped.Store(capped)
+}
+
+func isOverflow(set attribute.Set) bool {
+	v, ok := set.Value(overflowAttribute)
+	return ok && v.AsBool()
+}
+
+func hasOverflow(data metricdata.Aggregation) bool {
+	switch a := data.(type) {
+	case metricdata.Gauge[int64]:
+		return dataPointsOverflow(a.DataPoints)
+	case metricdata.Gauge[float64]:
+		return dataPointsOverflow(a.DataPoints)
+	case metricdata.Sum[int64]:
+		return dataPointsOverflow(a.DataPoints)
+	case metricdata.Sum[float64]:
+		return dataPointsOverflow(a.DataPoints)
+	case metricdata.Histogram[int64]:
+		return histogramOverflow(a.DataPoints)
+	case metricdata.Histogram[float64]:
+		return histogramOverflow(a.DataPoints)
+	case metricdata.ExponentialHistogram[int64]:
+		return exponentialHistogramOverflow(a.DataPoints)
+	case metricdata.ExponentialHistogram[float64]:
+		return exponentialHistogramOverflow(a.DataPoints)
+	}
+	return false
+}
+
+func dataPointsOverflow[N int64 | float64](dps []metricdata.DataPoint[N]) bool {
+	for _, dp := range dps {
+		if isOverflow(dp.Attributes) {
+			return true
+		}
+	}
+	return false
+}
+
+func histogramOverflow[N int64 | float64](dps []metricdata.HistogramDataPoint[N]) bool {
+	for _, dp := range dps {
+		if isOverflow(dp.Attributes) {
+			return true
+		}
+	}
+	return false
+}
+
+func exponentialHistogramOverflow[N int64 | float64](dps []metricdata.ExponentialHistogramDataPoint[N]) bool {
+	for _, dp := range dps {
+		if isOverflow(dp.Attributes) {
+			return true
+		}
+	}
+	return false
+}
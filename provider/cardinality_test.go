@@ -0,0 +1,74 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"os"
+	"testing"
+)
+
+// unsetEnv 清除环境变量，测试结束后恢复原来的值
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestCardinalityLimitOptIn(t *testing.T) {
+	unsetEnv(t, cardinalityLimitEnv)
+	c, err := NewConfig(WithServiceName("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MetricCardinalityLimit != 0 {
+		t.Fatalf("default cardinality limit = %d, want 0", c.MetricCardinalityLimit)
+	}
+	if limit := enableCardinalityLimit(c.MetricCardinalityLimit); limit != 0 {
+		t.Fatalf("effective limit = %d, want 0", limit)
+	}
+	if _, ok := os.LookupEnv(cardinalityLimitEnv); ok {
+		t.Fatalf("%s was set without a configured limit", cardinalityLimitEnv)
+	}
+}
+
+func TestCardinalityLimitEnv(t *testing.T) {
+	unsetEnv(t, cardinalityLimitEnv)
+	if limit := enableCardinalityLimit(100); limit != 100 || os.Getenv(cardinalityLimitEnv) != "100" {
+		t.Fatalf("limit = %d, env = %q", limit, os.Getenv(cardinalityLimitEnv))
+	}
+	// 再次初始化时limit为0，清除之前设置的值
+	enableCardinalityLimit(0)
+	if _, ok := os.LookupEnv(cardinalityLimitEnv); ok {
+		t.Fatalf("%s was not unset with limit 0", cardinalityLimitEnv)
+	}
+
+	enableCardinalityLimit(100)
+	resetSDKEnv(cardinalityLimitEnv)
+	if _, ok := os.LookupEnv(cardinalityLimitEnv); ok {
+		t.Fatalf("%s was not unset on shutdown", cardinalityLimitEnv)
+	}
+}
+
+func TestCardinalityLimitRespectsUserEnv(t *testing.T) {
+	t.Setenv(cardinalityLimitEnv, "50")
+	if limit := enableCardinalityLimit(100); limit != 50 {
+		t.Fatalf("effective limit = %d, want 50", limit)
+	}
+	enableCardinalityLimit(0)
+	resetSDKEnv(cardinalityLimitEnv)
+	if got := os.Getenv(cardinalityLimitEnv); got != "50" {
+		t.Fatalf("user value was changed to %q", got)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	diskBufferMinBackoff = time.Second
+	diskBufferMaxBackoff = time.Minute
+)
+
+// diskBuffer 把数据先写入磁盘队列，再由后台协程按顺序发送，发送失败时退避重试
+type diskBuffer struct {
+	queue  *diskQueue
+	send   func(ctx context.Context, payload []byte) error
+	sendMu sync.Mutex
+
+	notify    chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+func newDiskBuffer(dir string, maxSize int64, maxAge time.Duration, send func(context.Context, []byte) error) (*diskBuffer, error) {
+	q, err := openDiskQueue(dir, maxSize, maxAge)
+	if err != nil {
+		return nil, fmt.Errorf("open disk buffer %q: %w", dir, err)
+	}
+	return &diskBuffer{
+		queue:  q,
+		send:   send,
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}, nil
+}
+
+func (b *diskBuffer) start() {
+	b.startOnce.Do(func() {
+		go b.run()
+	})
+}
+
+func (b *diskBuffer) write(payload []byte) error {
+	if err := b.queue.Append(payload); err != nil {
+		return err
+	}
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (b *diskBuffer) run() {
+	defer close(b.done)
+	backoff := diskBufferMinBackoff
+	for {
+		sent, err := b.sendOne(context.Background())
+		if err != nil {
+			otel.Handle(err)
+			select {
+			case <-b.stop:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > diskBufferMaxBackoff {
+				backoff = diskBufferMaxBackoff
+			}
+			continue
+		}
+		backoff = diskBufferMinBackoff
+		if sent {
+			continue
+		}
+		select {
+		case <-b.stop:
+			return
+		case <-b.notify:
+		}
+	}
+}
+
+// sendOne 发送队首的一条记录，队列为空时返回false
+func (b *diskBuffer) sendOne(ctx context.Context) (bool, error) {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	payload, err := b.queue.Peek()
+	if err != nil || payload == nil {
+		return false, err
+	}
+	if err := b.send(ctx, payload); err != nil {
+		return false, err
+	}
+	b.queue.Commit()
+	return true, nil
+}
+
+// flush 尽量发送队列中的数据，发送失败时停止，剩余数据保留在磁盘上
+func (b *diskBuffer) flush(ctx context.Context) error {
+	for ctx.Err() == nil {
+		sent, err := b.sendOne(ctx)
+		if err != nil || !sent {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (b *diskBuffer) shutdown(ctx context.Context) error {
+	var err error
+	b.stopOnce.Do(func() {
+		close(b.stop)
+		b.startOnce.Do(func() { close(b.done) })
+		<-b.done
+		err = b.flush(ctx)
+		if closeErr := b.queue.Close(); err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
+
+// diskBufferedTraceClient 把Trace数据先写入磁盘队列再发送到服务端
+type diskBufferedTraceClient struct {
+	client otlptrace.Client
+	buffer *diskBuffer
+}
+
+func newDiskBufferedTraceClient(client otlptrace.Client, dir string, maxSize int64, maxAge time.Duration) (*diskBufferedTraceClient, error) {
+	c := &diskBufferedTraceClient{client: client}
+	buffer, err := newDiskBuffer(dir, maxSize, maxAge, c.upload)
+	if err != nil {
+		return nil, err
+	}
+	c.buffer = buffer
+	return c, nil
+}
+
+func (c *diskBufferedTraceClient) upload(ctx context.Context, payload []byte) error {
+	var data tracepb.TracesData
+	if err := proto.Unmarshal(payload, &data); err != nil {
+		// 无法解析的数据直接丢弃
+		otel.Handle(fmt.Errorf("drop corrupted traces in disk buffer: %w", err))
+		return nil
+	}
+	return c.client.UploadTraces(ctx, data.ResourceSpans)
+}
+
+func (c *diskBufferedTraceClient) Start(ctx context.Context) error {
+	if err := c.client.Start(ctx); err != nil {
+		return err
+	}
+	c.buffer.start()
+	return nil
+}
+
+func (c *diskBufferedTraceClient) Stop(ctx context.Context) error {
+	err := c.buffer.shutdown(ctx)
+	if stopErr := c.client.Stop(ctx); err == nil {
+		err = stopErr
+	}
+	return err
+}
+
+func (c *diskBufferedTraceClient) UploadTraces(_ context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	payload, err := proto.Marshal(&tracepb.TracesData{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+	return c.buffer.write(payload)
+}
+
+// diskBufferedMetricExporter 把Metric数据先写入磁盘队列再发送到服务端
+type diskBufferedMetricExporter struct {
+	metric.Exporter
+	buffer *diskBuffer
+}
+
+func newDiskBufferedMetricExporter(exporter metric.Exporter, dir string, maxSize int64, maxAge time.Duration) (*diskBufferedMetricExporter, error) {
+	e := &diskBufferedMetricExporter{Exporter: exporter}
+	buffer, err := newDiskBuffer(dir, maxSize, maxAge, e.upload)
+	if err != nil {
+		return nil, err
+	}
+	e.buffer = buffer
+	e.buffer.start()
+	return e, nil
+}
+
+func (e *diskBufferedMetricExporter) upload(ctx context.Context, payload []byte) error {
+	var data metricpb.MetricsData
+	if err := proto.Unmarshal(payload, &data); err != nil {
+		otel.Handle(fmt.Errorf("drop corrupted metrics in disk buffer: %w", err))
+		return nil
+	}
+	for _, rm := range data.ResourceMetrics {
+		if err := e.Exporter.Export(ctx, resourceMetricsFromProto(rm)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *diskBufferedMetricExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	payload, err := proto.Marshal(&metricpb.MetricsData{ResourceMetrics: []*metricpb.ResourceMetrics{resourceMetricsToProto(rm)}})
+	if err != nil {
+		return err
+	}
+	return e.buffer.write(payload)
+}
+
+func (e *diskBufferedMetricExporter) ForceFlush(ctx context.Context) error {
+	if err := e.buffer.flush(ctx); err != nil {
+		return err
+	}
+	return e.Exporter.ForceFlush(ctx)
+}
+
+func (e *diskBufferedMetricExporter) Shutdown(ctx context.Context) error {
+	err := e.buffer.shutdown(ctx)
+	if shutdownErr := e.Exporter.Shutdown(ctx); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
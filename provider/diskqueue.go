@@ -0,0 +1,416 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentSuffix     = ".seg"
+	cursorFile        = "cursor"
+	recordHeaderSize  = 16
+	maxSegmentSize    = 16 << 20
+	minSegmentSize    = 64 << 10
+	segmentsPerBuffer = 8
+)
+
+var errRecordCorrupted = errors.New("disk queue record corrupted")
+
+// diskQueue 基于本地文件的FIFO队列，数据按segment文件顺序追加写入，读取位置持久化在cursor文件中，进程重启后继续读取。
+// 每条记录的格式为: 4字节长度 | 8字节写入时间 | 4字节CRC32 | 数据
+// 同一个目录只能被一个进程使用
+type diskQueue struct {
+	dir         string
+	maxSize     int64
+	maxAge      time.Duration
+	segmentSize int64
+
+	mu         sync.Mutex
+	segments   []uint64
+	sizes      map[uint64]int64
+	writer     *os.File
+	readSeq    uint64
+	readOffset int64
+	reader     *os.File
+	peekSize   int64
+	count      int64
+	dropped    int64
+}
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", seq, segmentSuffix))
+}
+
+// openDiskQueue 打开或创建dir下的队列，已有的segment文件会被继续读取
+func openDiskQueue(dir string, maxSize int64, maxAge time.Duration) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	segmentSize := maxSize / segmentsPerBuffer
+	if segmentSize > maxSegmentSize {
+		segmentSize = maxSegmentSize
+	}
+	if segmentSize < minSegmentSize {
+		segmentSize = minSegmentSize
+	}
+	q := &diskQueue{dir: dir, maxSize: maxSize, maxAge: maxAge, segmentSize: segmentSize, sizes: map[uint64]int64{}}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, seq)
+		q.sizes[seq] = info.Size()
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i] < q.segments[j] })
+
+	q.loadCursor()
+	q.count = q.countRecords()
+
+	// 总是写入新的segment，避免在上次异常退出时写了一半的文件后追加
+	if err := q.rotate(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *diskQueue) loadCursor() {
+	if len(q.segments) == 0 {
+		return
+	}
+	q.readSeq, q.readOffset = q.segments[0], 0
+	b, err := os.ReadFile(filepath.Join(q.dir, cursorFile))
+	if err != nil {
+		return
+	}
+	var seq uint64
+	var offset int64
+	if _, err := fmt.Sscanf(string(b), "%d %d", &seq, &offset); err != nil {
+		return
+	}
+	if _, ok := q.sizes[seq]; ok && offset <= q.sizes[seq] {
+		q.readSeq, q.readOffset = seq, offset
+	}
+	// 删除已经读完的segment
+	for len(q.segments) > 0 && q.segments[0] < q.readSeq {
+		q.removeSegment(q.segments[0])
+	}
+}
+
+// countRecords 扫描未读取的记录数，仅在打开队列时调用
+func (q *diskQueue) countRecords() int64 {
+	var count int64
+	for _, seq := range q.segments {
+		count += q.segmentRecords(seq)
+	}
+	return count
+}
+
+// segmentRecords 扫描segment中未读取的记录数，长度超出文件大小的记录及其之后的数据不计数
+func (q *diskQueue) segmentRecords(seq uint64) int64 {
+	f, err := os.Open(segmentPath(q.dir, seq))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	offset := int64(0)
+	if seq == q.readSeq {
+		offset = q.readOffset
+	}
+	var count int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := f.ReadAt(header, offset); err != nil {
+			return count
+		}
+		offset += recordHeaderSize + int64(binary.BigEndian.Uint32(header[0:4]))
+		if offset > q.sizes[seq] {
+			return count
+		}
+		count++
+	}
+}
+
+func (q *diskQueue) rotate() error {
+	if q.writer != nil {
+		if err := q.writer.Close(); err != nil {
+			return err
+		}
+	}
+	seq := uint64(1)
+	if len(q.segments) > 0 {
+		seq = q.segments[len(q.segments)-1] + 1
+	}
+	f, err := os.OpenFile(segmentPath(q.dir, seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	q.writer = f
+	q.segments = append(q.segments, seq)
+	q.sizes[seq] = 0
+	if len(q.segments) == 1 {
+		q.readSeq, q.readOffset = seq, 0
+	}
+	return nil
+}
+
+func (q *diskQueue) writeSeq() uint64 {
+	return q.segments[len(q.segments)-1]
+}
+
+// Append 追加一条记录并落盘，超出大小限制时丢弃最早的segment
+func (q *diskQueue) Append(payload []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.writer == nil {
+		return errors.New("disk queue is closed")
+	}
+	if q.sizes[q.writeSeq()] >= q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	}
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint64(record[4:12], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint32(record[12:16], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+	if _, err := q.writer.Write(record); err != nil {
+		return err
+	}
+	if err := q.writer.Sync(); err != nil {
+		return err
+	}
+	q.sizes[q.writeSeq()] += int64(len(record))
+	q.count++
+
+	for q.size() > q.maxSize {
+		seq, ok := q.oldestDroppable()
+		if !ok {
+			break
+		}
+		q.dropSegment(seq)
+	}
+	return nil
+}
+
+// oldestDroppable 返回超出大小限制时可以丢弃的最早segment，不会丢弃正在写入的segment，
+// 发送协程Peek之后、Commit之前正在读取的segment也不会被丢弃
+func (q *diskQueue) oldestDroppable() (uint64, bool) {
+	for _, seq := range q.segments[:len(q.segments)-1] {
+		if seq == q.readSeq && q.peekSize > 0 {
+			continue
+		}
+		return seq, true
+	}
+	return 0, false
+}
+
+func (q *diskQueue) size() int64 {
+	var size int64
+	for _, seq := range q.segments {
+		size += q.sizes[seq]
+	}
+	return size - q.readOffset
+}
+
+// dropSegment 丢弃尚未读取的segment，并更新统计信息
+func (q *diskQueue) dropSegment(seq uint64) {
+	dropped := q.segmentRecords(seq)
+	q.dropped += dropped
+	q.count -= dropped
+	if q.count < 0 {
+		q.count = 0
+	}
+	q.removeSegment(seq)
+}
+
+func (q *diskQueue) removeSegment(seq uint64) {
+	if seq == q.readSeq {
+		q.closeReader()
+		q.peekSize = 0
+	}
+	os.Remove(segmentPath(q.dir, seq))
+	delete(q.sizes, seq)
+	for i, s := range q.segments {
+		if s == seq {
+			q.segments = append(q.segments[:i], q.segments[i+1:]...)
+			break
+		}
+	}
+	if len(q.segments) > 0 && seq == q.readSeq {
+		q.readSeq, q.readOffset = q.segments[0], 0
+	}
+}
+
+func (q *diskQueue) closeReader() {
+	if q.reader != nil {
+		q.reader.Close()
+		q.reader = nil
+	}
+}
+
+// Peek 返回队首的记录但不移除，队列为空时返回nil，超过maxAge的记录会被直接丢弃
+func (q *diskQueue) Peek() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if len(q.segments) == 0 {
+			return nil, nil
+		}
+		payload, createdAt, err := q.readRecord()
+		if err == io.EOF || err == errRecordCorrupted {
+			if q.readSeq == q.writeSeq() {
+				return nil, nil
+			}
+			// 当前segment已读完时删除，损坏时丢弃剩余的记录，继续读取下一个segment
+			if err == io.EOF {
+				q.removeSegment(q.readSeq)
+			} else {
+				q.dropSegment(q.readSeq)
+			}
+			q.saveCursor()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if q.maxAge > 0 && time.Since(createdAt) > q.maxAge {
+			q.dropped++
+			q.commit()
+			continue
+		}
+		return payload, nil
+	}
+}
+
+func (q *diskQueue) readRecord() ([]byte, time.Time, error) {
+	if q.reader == nil {
+		f, err := os.Open(segmentPath(q.dir, q.readSeq))
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		q.reader = f
+	}
+	header := make([]byte, recordHeaderSize)
+	if _, err := q.reader.ReadAt(header, q.readOffset); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, time.Time{}, errRecordCorrupted
+		}
+		return nil, time.Time{}, err
+	}
+	// 长度超出segment剩余大小说明记录已损坏，避免按损坏的长度分配内存
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	if length > q.sizes[q.readSeq]-q.readOffset-recordHeaderSize {
+		return nil, time.Time{}, errRecordCorrupted
+	}
+	payload := make([]byte, length)
+	if _, err := q.reader.ReadAt(payload, q.readOffset+recordHeaderSize); err != nil {
+		return nil, time.Time{}, errRecordCorrupted
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[12:16]) {
+		return nil, time.Time{}, errRecordCorrupted
+	}
+	q.peekSize = recordHeaderSize + int64(len(payload))
+	return payload, time.Unix(0, int64(binary.BigEndian.Uint64(header[4:12]))), nil
+}
+
+// Commit 移除Peek返回的记录
+func (q *diskQueue) Commit() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.commit()
+}
+
+func (q *diskQueue) commit() {
+	if q.peekSize == 0 {
+		return
+	}
+	q.readOffset += q.peekSize
+	q.peekSize = 0
+	q.count--
+	if q.count < 0 {
+		q.count = 0
+	}
+	q.saveCursor()
+}
+
+// saveCursor 持久化读取位置，先写临时文件再rename保证原子性
+func (q *diskQueue) saveCursor() {
+	tmp := filepath.Join(q.dir, cursorFile+".tmp")
+	if err := os.WriteFile(tmp, []byte(fmt.Sprintf("%d %d", q.readSeq, q.readOffset)), 0o644); err != nil {
+		return
+	}
+	os.Rename(tmp, filepath.Join(q.dir, cursorFile))
+}
+
+// Len 返回队列中未发送的记录数
+func (q *diskQueue) Len() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.count
+}
+
+// Size 返回队列中未发送的数据大小
+func (q *diskQueue) Size() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size()
+}
+
+// Dropped 返回因超出大小或时间限制被丢弃的记录数
+func (q *diskQueue) Dropped() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.dropped
+}
+
+// Close 关闭文件，未发送的数据保留在磁盘上，下次启动后继续发送
+func (q *diskQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closeReader()
+	if q.writer == nil {
+		return nil
+	}
+	err := q.writer.Close()
+	q.writer = nil
+	return err
+}
@@ -0,0 +1,199 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+)
+
+func openTestQueue(t *testing.T, dir string, maxSize int64, maxAge time.Duration) *diskQueue {
+	t.Helper()
+	q, err := openDiskQueue(dir, maxSize, maxAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func appendRecords(t *testing.T, q *diskQueue, payloads ...string) {
+	t.Helper()
+	for _, p := range payloads {
+		if err := q.Append([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// expectRecords 依次读取并提交记录，最后队列应为空
+func expectRecords(t *testing.T, q *diskQueue, payloads ...string) {
+	t.Helper()
+	for _, want := range payloads {
+		got, err := q.Peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Fatalf("Peek() = %q, want %q", got, want)
+		}
+		q.Commit()
+	}
+	if got, err := q.Peek(); err != nil || got != nil {
+		t.Fatalf("Peek() on empty queue = %q, %v", got, err)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Len() = %d on empty queue", q.Len())
+	}
+}
+
+func TestDiskQueueFIFO(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), 1<<20, 0)
+	appendRecords(t, q, "a", "b", "c")
+	if q.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", q.Len())
+	}
+	// 未Commit时重复Peek返回同一条记录
+	for i := 0; i < 2; i++ {
+		if got, _ := q.Peek(); string(got) != "a" {
+			t.Fatalf("Peek() = %q, want a", got)
+		}
+	}
+	expectRecords(t, q, "a", "b", "c")
+}
+
+func TestDiskQueueReopenAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openDiskQueue(dir, 1<<20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, q, "a", "b", "c")
+	q.Peek()
+	q.Commit()
+	// 模拟进程崩溃：不调用Close，文件句柄由测试进程退出时释放
+
+	q = openTestQueue(t, dir, 1<<20, 0)
+	if q.Len() != 2 {
+		t.Fatalf("Len() = %d after reopen, want 2", q.Len())
+	}
+	appendRecords(t, q, "d")
+	expectRecords(t, q, "b", "c", "d")
+}
+
+func TestDiskQueueTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openDiskQueue(dir, 1<<20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, q, "a", "b")
+	seq := q.writeSeq()
+	q.Close()
+
+	// 写入一半的记录：只有头部，数据不完整
+	f, err := os.OpenFile(segmentPath(dir, seq), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := make([]byte, recordHeaderSize)
+	binary.BigEndian.PutUint32(header[0:4], 100)
+	f.Write(append(header, "partial"...))
+	f.Close()
+
+	q = openTestQueue(t, dir, 1<<20, 0)
+	if q.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", q.Len())
+	}
+	appendRecords(t, q, "c")
+	expectRecords(t, q, "a", "b", "c")
+}
+
+// corruptSegment 修改segment中第一条记录的头部
+func corruptSegment(t *testing.T, dir string, seq uint64, corrupt func(header []byte)) {
+	t.Helper()
+	path := segmentPath(dir, seq)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt(b[:recordHeaderSize])
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiskQueueSkipsCorruptSegments(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(header []byte)
+	}{
+		{"huge length", func(header []byte) { binary.BigEndian.PutUint32(header[0:4], 0xffffffff) }},
+		{"bad checksum", func(header []byte) { header[12] ^= 0xff }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q, err := openDiskQueue(dir, 1<<20, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendRecords(t, q, "a", "b")
+			corrupted := q.writeSeq()
+			q.Close()
+			corruptSegment(t, dir, corrupted, tt.corrupt)
+
+			q = openTestQueue(t, dir, 1<<20, 0)
+			appendRecords(t, q, "c")
+			expectRecords(t, q, "c")
+			if _, err := os.Stat(segmentPath(dir, corrupted)); !os.IsNotExist(err) {
+				t.Fatalf("corrupted segment was not removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestDiskQueueKeepsPeekedSegment(t *testing.T) {
+	// 每条记录都超过segment大小，每次Append都会写入新的segment
+	payload := func(c byte) string { return string(bytes.Repeat([]byte{c}, minSegmentSize)) }
+	q := openTestQueue(t, t.TempDir(), 4*minSegmentSize, 0)
+	appendRecords(t, q, payload('a'))
+
+	got, err := q.Peek()
+	if err != nil || string(got) != payload('a') {
+		t.Fatalf("Peek() = %d bytes, %v", len(got), err)
+	}
+	// 发送过程中继续写入，正在读取的segment不能被丢弃
+	appendRecords(t, q, payload('b'), payload('c'), payload('d'))
+	q.Commit()
+
+	if q.Dropped() != 1 {
+		t.Fatalf("Dropped() = %d, want 1", q.Dropped())
+	}
+	expectRecords(t, q, payload('c'), payload('d'))
+}
+
+func TestDiskQueueMaxAge(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), 1<<20, time.Millisecond)
+	appendRecords(t, q, "old")
+	time.Sleep(5 * time.Millisecond)
+	expectRecords(t, q)
+	if q.Dropped() != 1 {
+		t.Fatalf("Dropped() = %d, want 1", q.Dropped())
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const redacted = "******"
+
+// secretEnvKeys 导出配置时需要隐藏的字段
+var secretEnvKeys = map[string]struct{}{
+	"SLS_OTEL_ACCESS_KEY_SECRET":  {},
+	"SLS_OTEL_REDACTION_HASH_KEY": {},
+}
+
+// urlEnvKeys 导出配置时需要隐藏密码的URL字段
+var urlEnvKeys = map[string]struct{}{
+	"SLS_OTEL_PROXY": {},
+}
+
+// Dump returns the effective configuration keyed by environment variable name, secrets are redacted
+// 导出当前生效的配置，key为对应的环境变量名称，密钥等敏感信息会被隐藏
+func (c *Config) Dump() map[string]string {
+	dump := map[string]string{}
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("env")
+		if tag == "" || !t.Field(i).IsExported() {
+			continue
+		}
+		key := strings.Split(tag, ",")[0]
+		value := fmt.Sprint(v.Field(i).Interface())
+		if _, ok := secretEnvKeys[key]; ok && value != "" {
+			value = redacted
+		}
+		if _, ok := urlEnvKeys[key]; ok {
+			value = redactURL(value)
+		}
+		dump[key] = value
+	}
+	limits := c.spanLimits()
+	dump["OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT"] = fmt.Sprint(limits.AttributeCountLimit)
+	dump["OTEL_SPAN_ATTRIBUTE_VALUE_LENGTH_LIMIT"] = fmt.Sprint(limits.AttributeValueLengthLimit)
+	dump["OTEL_SPAN_EVENT_COUNT_LIMIT"] = fmt.Sprint(limits.EventCountLimit)
+	dump["OTEL_SPAN_LINK_COUNT_LIMIT"] = fmt.Sprint(limits.LinkCountLimit)
+	dump["OTEL_EVENT_ATTRIBUTE_COUNT_LIMIT"] = fmt.Sprint(limits.AttributePerEventCountLimit)
+	dump["OTEL_LINK_ATTRIBUTE_COUNT_LIMIT"] = fmt.Sprint(limits.AttributePerLinkCountLimit)
+	return dump
+}
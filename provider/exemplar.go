@@ -0,0 +1,55 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"fmt"
+)
+
+// exemplar filters
+const (
+	// ExemplarFilterAlwaysOn 所有测量值都可以作为exemplar
+	ExemplarFilterAlwaysOn = "always_on"
+	// ExemplarFilterAlwaysOff 不记录exemplar
+	ExemplarFilterAlwaysOff = "always_off"
+	// ExemplarFilterTraceBased 只记录当前Span被采样时的测量值，exemplar中带有trace_id和span_id
+	ExemplarFilterTraceBased = "trace_based"
+)
+
+const (
+	// SDK的exemplar只能通过环境变量开启，见setSDKEnv
+	exemplarEnv       = "OTEL_GO_X_EXEMPLAR"
+	exemplarFilterEnv = "OTEL_METRICS_EXEMPLAR_FILTER"
+)
+
+func validateExemplarFilter(filter string) error {
+	switch filter {
+	case ExemplarFilterAlwaysOn, ExemplarFilterAlwaysOff, ExemplarFilterTraceBased:
+		return nil
+	}
+	return fmt.Errorf("unknown exemplar filter %q, must be one of always_on, always_off, trace_based", filter)
+}
+
+// enableExemplars 按照filter开启SDK的exemplar，Histogram和Counter的数据点会带上当前Span的trace_id和span_id，
+// 用户已经设置的OTEL_GO_X_EXEMPLAR和OTEL_METRICS_EXEMPLAR_FILTER不会被修改
+func enableExemplars(filter string) {
+	if filter == ExemplarFilterAlwaysOff {
+		setSDKEnv(exemplarEnv, "false")
+		setSDKEnv(exemplarFilterEnv, "")
+		return
+	}
+	setSDKEnv(exemplarEnv, "true")
+	setSDKEnv(exemplarFilterEnv, filter)
+}
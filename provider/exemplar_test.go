@@ -0,0 +1,52 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnableExemplars(t *testing.T) {
+	unsetEnv(t, exemplarEnv)
+	unsetEnv(t, exemplarFilterEnv)
+
+	enableExemplars(ExemplarFilterTraceBased)
+	if os.Getenv(exemplarEnv) != "true" || os.Getenv(exemplarFilterEnv) != ExemplarFilterTraceBased {
+		t.Fatalf("env = %q, %q", os.Getenv(exemplarEnv), os.Getenv(exemplarFilterEnv))
+	}
+	enableExemplars(ExemplarFilterAlwaysOff)
+	if _, ok := os.LookupEnv(exemplarFilterEnv); os.Getenv(exemplarEnv) != "false" || ok {
+		t.Fatalf("env = %q, %q", os.Getenv(exemplarEnv), os.Getenv(exemplarFilterEnv))
+	}
+
+	resetSDKEnv(exemplarEnv, exemplarFilterEnv)
+	for _, key := range []string{exemplarEnv, exemplarFilterEnv} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Fatalf("%s was not unset on shutdown", key)
+		}
+	}
+}
+
+func TestEnableExemplarsRespectsUserEnv(t *testing.T) {
+	t.Setenv(exemplarEnv, "false")
+	t.Setenv(exemplarFilterEnv, ExemplarFilterAlwaysOn)
+
+	enableExemplars(ExemplarFilterTraceBased)
+	resetSDKEnv(exemplarEnv, exemplarFilterEnv)
+	if os.Getenv(exemplarEnv) != "false" || os.Getenv(exemplarFilterEnv) != ExemplarFilterAlwaysOn {
+		t.Fatalf("user values were changed to %q, %q", os.Getenv(exemplarEnv), os.Getenv(exemplarFilterEnv))
+	}
+}
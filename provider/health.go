@@ -0,0 +1,182 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+const healthPath = "/healthz"
+
+const (
+	statusOK         = "ok"
+	statusFailing    = "failing"
+	statusUnhealthy  = "unhealthy"
+	statusDisabled   = "disabled"
+	statusNotStarted = "not_started"
+)
+
+// queueUsage 队列的长度和占配置的最大长度的比例
+type queueUsage struct {
+	Size        int64   `json:"size"`
+	MaxSize     int64   `json:"max_size"`
+	Utilization float64 `json:"utilization"`
+}
+
+func newQueueUsage(size, maxSize int64) *queueUsage {
+	usage := &queueUsage{Size: size, MaxSize: maxSize}
+	if maxSize > 0 {
+		usage.Utilization = float64(size) / float64(maxSize)
+	}
+	return usage
+}
+
+type queueStatus struct {
+	// BatchSpanProcessor队列中的Span，只有Trace有
+	Spans       *queueUsage `json:"spans,omitempty"`
+	Disk        int64       `json:"disk"`
+	DiskDropped int64       `json:"disk_dropped"`
+	// 磁盘缓存占用的字节数，配置磁盘缓存时才有
+	DiskBytes *queueUsage `json:"disk_bytes,omitempty"`
+}
+
+type signalStatus struct {
+	Status        string      `json:"status"`
+	Endpoint      string      `json:"endpoint"`
+	Exported      int64       `json:"exported"`
+	Failed        int64       `json:"failed"`
+	Dropped       int64       `json:"dropped"`
+	LastSuccess   *time.Time  `json:"last_success,omitempty"`
+	LastError     string      `json:"last_error,omitempty"`
+	LastErrorTime *time.Time  `json:"last_error_time,omitempty"`
+	FailingSince  *time.Time  `json:"failing_since,omitempty"`
+	Queue         queueStatus `json:"queue"`
+}
+
+type healthStatus struct {
+	Status  string                  `json:"status"`
+	Signals map[string]signalStatus `json:"signals"`
+	Config  map[string]string       `json:"config"`
+}
+
+func unixNanoTime(ts int64) *time.Time {
+	if ts == 0 {
+		return nil
+	}
+	t := time.Unix(0, ts)
+	return &t
+}
+
+// status 根据最近的导出结果计算单个数据类型的状态，持续失败超过threshold时为unhealthy
+func (s *signalStats) status(endpoint string, threshold time.Duration) signalStatus {
+	if endpoint == "" {
+		return signalStatus{Status: statusDisabled}
+	}
+	st := signalStatus{
+		Status:        statusOK,
+		Endpoint:      endpoint,
+		Exported:      s.exported.Load(),
+		Failed:        s.failures.Load(),
+		Dropped:       s.dropped(),
+		LastSuccess:   unixNanoTime(s.lastSuccess.Load()),
+		LastError:     s.error(),
+		LastErrorTime: unixNanoTime(s.lastFailure.Load()),
+		FailingSince:  unixNanoTime(s.failingSince.Load()),
+		Queue: queueStatus{
+			Disk:        s.diskQueueSize(),
+			DiskDropped: s.diskDropped(),
+		},
+	}
+	if s.queue != nil {
+		st.Queue.Spans = newQueueUsage(s.queue.size.Load(), s.queue.maxSize)
+	}
+	if q := s.diskQueue.Load(); q != nil {
+		st.Queue.DiskBytes = newQueueUsage(q.Size(), q.maxSize)
+	}
+	if st.FailingSince != nil {
+		st.Status = statusFailing
+		if time.Since(*st.FailingSince) > threshold {
+			st.Status = statusUnhealthy
+		}
+	}
+	return st
+}
+
+// health 返回导出链路的整体状态
+func (c *Config) health() (healthStatus, bool) {
+	h := healthStatus{Status: statusOK, Signals: map[string]signalStatus{}, Config: c.Dump()}
+	stats := c.stats.Load()
+	if stats == nil {
+		h.Status = statusNotStarted
+		return h, false
+	}
+	healthy := true
+	h.Signals[stats.traces.name] = stats.traces.status(c.TraceExporterEndpoint, c.HealthFailureThreshold)
+	h.Signals[stats.metrics.name] = stats.metrics.status(c.MetricExporterEndpoint, c.HealthFailureThreshold)
+	h.Signals[stats.logs.name] = stats.logs.status(c.LogExporterEndpoint, c.HealthFailureThreshold)
+	for _, st := range h.Signals {
+		switch st.Status {
+		case statusUnhealthy:
+			h.Status, healthy = statusUnhealthy, false
+		case statusFailing:
+			if h.Status == statusOK {
+				h.Status = statusFailing
+			}
+		}
+	}
+	return h, healthy
+}
+
+// HealthHandler returns a http.Handler reporting the exporter status in JSON,
+// it responds 503 when export has been failing longer than the failure threshold
+// 返回报告导出状态的HTTP Handler，导出持续失败超过阈值时返回503，可用于Kubernetes探针
+func HealthHandler(c *Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, healthy := c.health()
+		w.Header().Set("Content-Type", "application/json")
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(h)
+	})
+}
+
+// startHealthServer 在HealthListenAddr上启动独立的健康检查服务
+func (c *Config) startHealthServer() error {
+	ln, err := net.Listen("tcp", c.HealthListenAddr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(healthPath, HealthHandler(c))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			otel.Handle(err)
+		}
+	}()
+	c.stop = append(c.stop, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+	return nil
+}
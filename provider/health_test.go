@@ -0,0 +1,199 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// fakeLogExporter 返回err的日志Exporter
+type fakeLogExporter struct {
+	err error
+}
+
+func (e *fakeLogExporter) Export(context.Context, []sdklog.Record) error { return e.err }
+
+func (e *fakeLogExporter) Shutdown(context.Context) error { return nil }
+
+func (e *fakeLogExporter) ForceFlush(context.Context) error { return nil }
+
+func TestHealthReportsDiskBufferSendFailures(t *testing.T) {
+	c := &Config{TraceExporterEndpoint: "collector:4317", HealthFailureThreshold: time.Hour}
+	stats := newPipelineStats(c)
+	c.stats.Store(stats)
+	server := &fakeTraceClient{err: errors.New("unavailable")}
+	client, err := newDiskBufferedTraceClient(&instrumentedTraceClient{Client: server, stats: stats.traces}, t.TempDir(), 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats.traces.diskQueue.Store(client.buffer.queue)
+	defer client.buffer.queue.Close()
+
+	ctx := context.Background()
+	if err := client.UploadTraces(ctx, testResourceSpans(2)); err != nil {
+		t.Fatal(err)
+	}
+	client.buffer.flush(ctx)
+
+	h, healthy := c.health()
+	traces := h.Signals["traces"]
+	if !healthy || h.Status != statusFailing || traces.Status != statusFailing {
+		t.Fatalf("status = %q, traces = %q, want failing", h.Status, traces.Status)
+	}
+	if traces.LastSuccess != nil || traces.Exported != 0 || traces.Queue.Disk != 1 {
+		t.Fatalf("unexpected traces status %+v", traces)
+	}
+	if disk := traces.Queue.DiskBytes; disk == nil || disk.MaxSize != 1<<20 || disk.Size == 0 ||
+		disk.Utilization != float64(disk.Size)/float64(1<<20) {
+		t.Fatalf("unexpected disk usage %+v", disk)
+	}
+
+	c.HealthFailureThreshold = time.Nanosecond
+	rec := httptest.NewRecorder()
+	HealthHandler(c).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, healthPath, nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("health handler returned %d, want 503", rec.Code)
+	}
+
+	server.err = nil
+	if err := client.buffer.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if h, healthy := c.health(); !healthy || h.Status != statusOK {
+		t.Fatalf("status = %q after a successful send", h.Status)
+	}
+}
+
+func TestHealthReportsLogs(t *testing.T) {
+	c := &Config{HealthFailureThreshold: time.Hour}
+	stats := newPipelineStats(c)
+	c.stats.Store(stats)
+	h, _ := c.health()
+	if logs := h.Signals["logs"]; logs.Status != statusDisabled {
+		t.Fatalf("logs status = %q without endpoint, want disabled", logs.Status)
+	}
+
+	c.LogExporterEndpoint = "collector:4317"
+	server := &fakeLogExporter{err: errors.New("unavailable")}
+	exporter := &instrumentedLogExporter{Exporter: server, stats: stats.logs}
+	ctx := context.Background()
+	if err := exporter.Export(ctx, make([]sdklog.Record, 3)); err == nil {
+		t.Fatal("expected export error")
+	}
+	h, healthy := c.health()
+	logs := h.Signals["logs"]
+	if !healthy || h.Status != statusFailing || logs.Status != statusFailing || logs.Dropped != 3 || logs.LastError != "unavailable" {
+		t.Fatalf("status = %q, logs = %+v", h.Status, logs)
+	}
+
+	server.err = nil
+	if err := exporter.Export(ctx, make([]sdklog.Record, 2)); err != nil {
+		t.Fatal(err)
+	}
+	h, healthy = c.health()
+	if logs := h.Signals["logs"]; !healthy || logs.Status != statusOK || logs.Exported != 2 {
+		t.Fatalf("status = %q, logs = %+v", h.Status, logs)
+	}
+	if summary := stats.logs.summary(); !strings.Contains(summary, "logs exported=2 failed=1 dropped=3") {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+}
+
+func TestHealthReportsQueueUtilization(t *testing.T) {
+	c := &Config{TraceExporterEndpoint: "collector:4317", BSPMaxQueueSize: 8, HealthFailureThreshold: time.Hour}
+	stats := newPipelineStats(c)
+	c.stats.Store(stats)
+	for i := 0; i < 2; i++ {
+		stats.traces.queue.enqueue()
+	}
+	h, _ := c.health()
+	if spans := h.Signals["traces"].Queue.Spans; spans == nil || *spans != (queueUsage{Size: 2, MaxSize: 8, Utilization: 0.25}) {
+		t.Fatalf("unexpected span queue %+v", spans)
+	}
+	if queue := h.Signals["traces"].Queue; queue.DiskBytes != nil {
+		t.Fatalf("disk usage reported without disk buffer: %+v", queue.DiskBytes)
+	}
+	if spans := h.Signals["metrics"].Queue.Spans; spans != nil {
+		t.Fatalf("span queue reported for metrics: %+v", spans)
+	}
+}
+
+func TestHealthDuringStart(t *testing.T) {
+	c, err := NewConfig(WithServiceName("test"), WithTraceExporterEndpoint("stdout"), WithMetricExporterEndpoint(""),
+		WithLogExporterEndpoint(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, healthy := c.health(); healthy || h.Status != statusNotStarted {
+		t.Fatalf("status = %q before Start", h.Status)
+	}
+	// 健康检查与Start并发执行，通过-race检查数据竞争
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			c.health()
+		}
+	}()
+	if err := Start(c); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown(c)
+	<-done
+	if h, healthy := c.health(); !healthy || h.Status != statusOK {
+		t.Fatalf("status = %q after Start", h.Status)
+	}
+}
+
+// freeAddr 返回一个当前未被占用的本地地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestHealthServerNotStartedOnInitFailure(t *testing.T) {
+	addr := freeAddr(t)
+	c := &Config{
+		ServiceName:            "test",
+		TraceExporterEndpoint:  "stdout",
+		HealthListenAddr:       addr,
+		HealthFailureThreshold: time.Minute,
+		// 空规则在初始化Trace时校验失败
+		SpanFilterRules: []SpanFilterRule{{}},
+	}
+	if err := Start(c); err == nil {
+		Shutdown(c)
+		t.Fatal("Start should fail with an empty span filter rule")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("health server is still listening after Start failed: %v", err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,71 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/contrib/instrumentation/host"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// host metric groups
+const (
+	HostMetricsCPU     = "cpu"
+	HostMetricsMemory  = "memory"
+	HostMetricsNetwork = "network"
+)
+
+// hostMetricGroups 主机指标分组对应的指标名
+var hostMetricGroups = map[string][]string{
+	HostMetricsCPU:     {"process.cpu.time", "system.cpu.time"},
+	HostMetricsMemory:  {"system.memory.usage", "system.memory.utilization"},
+	HostMetricsNetwork: {"system.network.io"},
+}
+
+func (c *Config) validateHostMetricsGroups() error {
+	for _, group := range splitList(c.HostMetricsGroups) {
+		if _, ok := hostMetricGroups[group]; !ok {
+			return fmt.Errorf("unknown host metrics group %q, must be one of cpu, memory, network", group)
+		}
+	}
+	return nil
+}
+
+// hostMetricsEnabled 没有开启任何分组时不启动主机指标采集
+func (c *Config) hostMetricsEnabled() bool {
+	return c.HostMetricsEnabled && len(splitList(c.HostMetricsGroups)) > 0
+}
+
+// hostMetricsViews 丢弃未开启分组的主机指标
+func (c *Config) hostMetricsViews() []metric.View {
+	enabled := map[string]bool{}
+	for _, group := range splitList(c.HostMetricsGroups) {
+		enabled[group] = true
+	}
+	var views []metric.View
+	for group, names := range hostMetricGroups {
+		if enabled[group] {
+			continue
+		}
+		for _, name := range names {
+			views = append(views, metric.NewView(
+				metric.Instrument{Name: name, Scope: instrumentation.Scope{Name: host.ScopeName}},
+				metric.Stream{Aggregation: metric.AggregationDrop{}}))
+		}
+	}
+	return views
+}
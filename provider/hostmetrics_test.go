@@ -0,0 +1,45 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import "testing"
+
+func TestHostMetricsEnabled(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		groups  string
+		want    bool
+	}{
+		{true, "cpu,memory,network", true},
+		{true, "cpu", true},
+		{true, "", false},
+		{true, " , ", false},
+		{false, "cpu", false},
+	}
+	for _, tt := range tests {
+		c := &Config{HostMetricsEnabled: tt.enabled, HostMetricsGroups: tt.groups}
+		if got := c.hostMetricsEnabled(); got != tt.want {
+			t.Errorf("hostMetricsEnabled(%v, %q) = %v, want %v", tt.enabled, tt.groups, got, tt.want)
+		}
+	}
+}
+
+func TestHostMetricsViewsDropDisabledGroups(t *testing.T) {
+	c := &Config{HostMetricsEnabled: true, HostMetricsGroups: "cpu"}
+	want := len(hostMetricGroups[HostMetricsMemory]) + len(hostMetricGroups[HostMetricsNetwork])
+	if got := len(c.hostMetricsViews()); got != want {
+		t.Fatalf("got %d drop views, want %d", got, want)
+	}
+}
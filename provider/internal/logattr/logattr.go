@@ -0,0 +1,129 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logattr converts fields of logging libraries to OpenTelemetry log attributes
+// 把日志库的字段转换为OpenTelemetry日志属性，供zap、logrus等日志桥接使用
+package logattr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+)
+
+// Value 把Go的值转换为log.Value，无法识别的类型使用fmt格式化为字符串
+func Value(v any) log.Value {
+	switch x := v.(type) {
+	case nil:
+		return log.Value{}
+	case string:
+		return log.StringValue(x)
+	case bool:
+		return log.BoolValue(x)
+	case int:
+		return log.IntValue(x)
+	case int8:
+		return log.Int64Value(int64(x))
+	case int16:
+		return log.Int64Value(int64(x))
+	case int32:
+		return log.Int64Value(int64(x))
+	case int64:
+		return log.Int64Value(x)
+	case uint:
+		return uintValue(uint64(x))
+	case uint8:
+		return log.Int64Value(int64(x))
+	case uint16:
+		return log.Int64Value(int64(x))
+	case uint32:
+		return log.Int64Value(int64(x))
+	case uint64:
+		return uintValue(x)
+	case uintptr:
+		return uintValue(uint64(x))
+	case float32:
+		return log.Float64Value(float64(x))
+	case float64:
+		return log.Float64Value(x)
+	case []byte:
+		return log.BytesValue(x)
+	case time.Duration:
+		return log.Int64Value(x.Nanoseconds())
+	case time.Time:
+		return log.Int64Value(x.UnixNano())
+	case error:
+		return log.StringValue(x.Error())
+	case fmt.Stringer:
+		return log.StringValue(x.String())
+	case []any:
+		values := make([]log.Value, 0, len(x))
+		for _, item := range x {
+			values = append(values, Value(item))
+		}
+		return log.SliceValue(values...)
+	case map[string]any:
+		return log.MapValue(KeyValues(x)...)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return log.Value{}
+		}
+		return Value(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		values := make([]log.Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, Value(rv.Index(i).Interface()))
+		}
+		return log.SliceValue(values...)
+	}
+	return log.StringValue(fmt.Sprintf("%+v", v))
+}
+
+func uintValue(v uint64) log.Value {
+	if v > math.MaxInt64 {
+		return log.StringValue(fmt.Sprint(v))
+	}
+	return log.Int64Value(int64(v))
+}
+
+// KeyValues 把字段转换为按key排序的属性列表
+func KeyValues(fields map[string]any) []log.KeyValue {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	kvs := make([]log.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		kvs = append(kvs, log.KeyValue{Key: key, Value: Value(fields[key])})
+	}
+	return kvs
+}
+
+// Caller 返回日志调用位置对应的code.*属性
+func Caller(file string, line int, function string) []log.KeyValue {
+	return []log.KeyValue{
+		log.String("code.filepath", file),
+		log.Int("code.lineno", line),
+		log.String("code.function", function),
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logattr
+
+import (
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+)
+
+func TestValue(t *testing.T) {
+	n := 7
+	var nilPointer *int
+	now := time.Unix(100, 5)
+	tests := []struct {
+		name string
+		in   any
+		want log.Value
+	}{
+		{"nil", nil, log.Value{}},
+		{"string", "a", log.StringValue("a")},
+		{"bool", true, log.BoolValue(true)},
+		{"int", 1, log.IntValue(1)},
+		{"int32", int32(-2), log.Int64Value(-2)},
+		{"uint16", uint16(3), log.Int64Value(3)},
+		{"uint64 overflow", uint64(math.MaxUint64), log.StringValue("18446744073709551615")},
+		{"float32", float32(1.5), log.Float64Value(1.5)},
+		{"bytes", []byte("raw"), log.BytesValue([]byte("raw"))},
+		{"duration", time.Second, log.Int64Value(int64(time.Second))},
+		{"time", now, log.Int64Value(now.UnixNano())},
+		{"error", errors.New("failed"), log.StringValue("failed")},
+		{"stringer", net.IPv4(127, 0, 0, 1), log.StringValue("127.0.0.1")},
+		{"any slice", []any{"a", 1}, log.SliceValue(log.StringValue("a"), log.IntValue(1))},
+		{"typed slice", []string{"a", "b"}, log.SliceValue(log.StringValue("a"), log.StringValue("b"))},
+		{"map", map[string]any{"b": 2, "a": "x"}, log.MapValue(log.String("a", "x"), log.Int("b", 2))},
+		{"pointer", &n, log.IntValue(7)},
+		{"nil pointer", nilPointer, log.Value{}},
+		{"struct", struct{ A int }{1}, log.StringValue("{A:1}")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Value(tt.in); !got.Equal(tt.want) {
+				t.Fatalf("Value(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyValuesSorted(t *testing.T) {
+	kvs := KeyValues(map[string]any{"c": 3, "a": 1, "b": 2})
+	if len(kvs) != 3 || kvs[0].Key != "a" || kvs[1].Key != "b" || kvs[2].Key != "c" {
+		t.Fatalf("unexpected key values %v", kvs)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logtest provides fixtures shared by the tests of the log bridges
+// 日志桥接测试共用的Exporter、LoggerProvider和Trace上下文
+package logtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecordingExporter 记录导出的日志
+type RecordingExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+// Export 实现sdklog.Exporter
+func (e *RecordingExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+// Shutdown 实现sdklog.Exporter
+func (e *RecordingExporter) Shutdown(context.Context) error { return nil }
+
+// ForceFlush 实现sdklog.Exporter
+func (e *RecordingExporter) ForceFlush(context.Context) error { return nil }
+
+// Records 返回已导出的日志
+func (e *RecordingExporter) Records() []sdklog.Record {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]sdklog.Record(nil), e.records...)
+}
+
+// Reset 清空已导出的日志
+func (e *RecordingExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.records = nil
+}
+
+// NewLoggerProvider 创建同步导出到RecordingExporter的LoggerProvider，测试结束时关闭
+func NewLoggerProvider(t *testing.T) (*sdklog.LoggerProvider, *RecordingExporter) {
+	t.Helper()
+	exporter := &RecordingExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return provider, exporter
+}
+
+// ContextWithSpan 返回带有固定的已采样Span上下文的Context
+func ContextWithSpan() (context.Context, trace.SpanContext) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc), sc
+}
+
+// Attributes 返回日志的属性，按属性名索引
+func Attributes(r sdklog.Record) map[string]log.Value {
+	attrs := map[string]log.Value{}
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	return attrs
+}
@@ -0,0 +1,155 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logrus correlates logrus entries with traces: the Hook adds trace_id, span_id and trace_flags of
+// the span in the entry context (set by logrus.WithContext) to every entry, and optionally forwards entries
+// to the OpenTelemetry Logs pipeline created by provider.Start, register provider.Shutdown with
+// logrus.RegisterExitHandler so that fatal entries are exported before the process exits
+// 为logrus日志添加trace_id、span_id和trace_flags，并可以把日志转发到provider创建的OpenTelemetry Logs，
+// 通过logrus.RegisterExitHandler注册provider.Shutdown，确保进程退出前Fatal日志已经发送
+package logrus
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider"
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/internal/logattr"
+)
+
+// ScopeName is the instrumentation scope name of forwarded entries
+const ScopeName = "github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/logrus"
+
+// field names of the trace context
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// Option configures the Hook
+type Option func(*Hook)
+
+// WithLoggerProvider forwards entries to the OpenTelemetry Logs pipeline of provider, usually config.LoggerProvider()
+// after provider.Start, entries are not forwarded when provider is nil
+// 把日志转发到OpenTelemetry Logs，通常传入provider.Start之后的config.LoggerProvider()，为nil时不转发
+func WithLoggerProvider(provider log.LoggerProvider) Option {
+	return func(h *Hook) {
+		if provider != nil {
+			h.logger = provider.Logger(ScopeName)
+		}
+	}
+}
+
+// WithLevel configures the minimum level of entries forwarded to OpenTelemetry Logs, defaults to logrus.InfoLevel
+// 配置转发到OpenTelemetry Logs的最低日志级别，默认为logrus.InfoLevel
+func WithLevel(level logrus.Level) Option {
+	return func(h *Hook) {
+		h.level = level
+	}
+}
+
+// WithRedactor redacts the message and fields of forwarded entries, usually the redactor returned by config.Redactor(),
+// which is nil when redaction is not enabled, entries printed by the logger are not redacted
+// 脱敏转发到OpenTelemetry Logs的日志内容和字段，通常传入config.Redactor()，未开启脱敏时为nil
+func WithRedactor(redactor *provider.Redactor) Option {
+	return func(h *Hook) {
+		h.redactor = redactor
+	}
+}
+
+// Hook adds the trace context to entries and forwards them to OpenTelemetry Logs, it fires for all levels
+// so that entries printed by the logger always carry trace fields
+// 在日志中添加Trace上下文并转发到OpenTelemetry Logs，所有级别的日志都会触发
+type Hook struct {
+	logger   log.Logger
+	level    logrus.Level
+	redactor *provider.Redactor
+}
+
+var _ logrus.Hook = (*Hook)(nil)
+
+// NewHook creates a Hook, register it with logger.AddHook
+// 创建Hook，通过logger.AddHook注册
+func NewHook(opts ...Option) *Hook {
+	h := &Hook{level: logrus.InfoLevel}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Levels implements logrus.Hook
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook, trace fields are added only when the entry context contains a valid span context
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if h.logger != nil && entry.Level <= h.level {
+		h.logger.Emit(ctx, h.convertEntry(entry))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		entry.Data[TraceIDKey] = sc.TraceID().String()
+		entry.Data[SpanIDKey] = sc.SpanID().String()
+		entry.Data[TraceFlagsKey] = sc.TraceFlags().String()
+	}
+	return nil
+}
+
+func (h *Hook) convertEntry(entry *logrus.Entry) log.Record {
+	var record log.Record
+	record.SetTimestamp(entry.Time)
+	message, kvs := entry.Message, logattr.KeyValues(entry.Data)
+	if h.redactor != nil {
+		message, kvs = h.redactor.RedactString(message), h.redactor.RedactLogKeyValues(kvs)
+	}
+	record.SetBody(log.StringValue(message))
+	record.SetSeverity(severity(entry.Level))
+	record.SetSeverityText(strings.ToUpper(entry.Level.String()))
+	record.AddAttributes(kvs...)
+	if entry.HasCaller() {
+		record.AddAttributes(logattr.Caller(entry.Caller.File, entry.Caller.Line, entry.Caller.Function)...)
+	}
+	return record
+}
+
+// severity 把logrus的日志级别转换为OpenTelemetry的日志级别
+func severity(level logrus.Level) log.Severity {
+	switch level {
+	case logrus.TraceLevel:
+		return log.SeverityTrace
+	case logrus.DebugLevel:
+		return log.SeverityDebug
+	case logrus.InfoLevel:
+		return log.SeverityInfo
+	case logrus.WarnLevel:
+		return log.SeverityWarn
+	case logrus.ErrorLevel:
+		return log.SeverityError
+	case logrus.FatalLevel:
+		return log.SeverityFatal
+	case logrus.PanicLevel:
+		return log.SeverityFatal2
+	}
+	return log.SeverityUndefined
+}
@@ -0,0 +1,145 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/log"
+
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider"
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/internal/logtest"
+)
+
+func newLogger(hook *Hook) (*logrus.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(hook)
+	return logger, &buf
+}
+
+func TestHookAddsTraceContext(t *testing.T) {
+	logger, buf := newLogger(NewHook())
+	ctx, sc := logtest.ContextWithSpan()
+
+	logger.WithContext(ctx).WithField("path", "/users").Info("hello")
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got[TraceIDKey] != sc.TraceID().String() || got[SpanIDKey] != sc.SpanID().String() ||
+		got[TraceFlagsKey] != sc.TraceFlags().String() || got["path"] != "/users" {
+		t.Fatalf("unexpected entry %s", buf.String())
+	}
+
+	buf.Reset()
+	logger.Info("no span")
+	if bytes.Contains(buf.Bytes(), []byte(TraceIDKey)) {
+		t.Fatalf("trace fields added without span context: %s", buf.String())
+	}
+}
+
+func TestHookForwardsEntries(t *testing.T) {
+	provider, exporter := logtest.NewLoggerProvider(t)
+	logger, _ := newLogger(NewHook(WithLoggerProvider(provider)))
+	logger.SetReportCaller(true)
+	ctx, sc := logtest.ContextWithSpan()
+
+	logger.Debug("filtered")
+	logger.WithContext(ctx).WithFields(logrus.Fields{"service": "demo", "status": 200}).Warn("slow request")
+
+	if len(exporter.Records()) != 1 {
+		t.Fatalf("exported %d records, want 1", len(exporter.Records()))
+	}
+	r := exporter.Records()[0]
+	if r.Body().AsString() != "slow request" || r.Severity() != log.SeverityWarn || r.SeverityText() != "WARNING" {
+		t.Fatalf("body = %v, severity = %v, severity text = %q", r.Body(), r.Severity(), r.SeverityText())
+	}
+	if r.TraceID() != sc.TraceID() || r.SpanID() != sc.SpanID() {
+		t.Fatalf("record not correlated with span: %v %v", r.TraceID(), r.SpanID())
+	}
+	attrs := map[string]log.Value{}
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	if attrs["service"].AsString() != "demo" || attrs["status"].AsInt64() != 200 {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+	if attrs["code.lineno"].AsInt64() == 0 || attrs["code.function"].AsString() == "" {
+		t.Fatalf("missing caller attributes %v", attrs)
+	}
+}
+
+func TestHookLevel(t *testing.T) {
+	provider, exporter := logtest.NewLoggerProvider(t)
+	logger, buf := newLogger(NewHook(WithLoggerProvider(provider), WithLevel(logrus.ErrorLevel)))
+	logger.Warn("warn")
+	logger.Error("error")
+	if len(exporter.Records()) != 1 || exporter.Records()[0].Severity() != log.SeverityError {
+		t.Fatalf("unexpected records %v", exporter.Records())
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("\n")); n != 2 {
+		t.Fatalf("printed %d entries, want 2", n)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for level, want := range map[logrus.Level]log.Severity{
+		logrus.TraceLevel: log.SeverityTrace,
+		logrus.DebugLevel: log.SeverityDebug,
+		logrus.InfoLevel:  log.SeverityInfo,
+		logrus.WarnLevel:  log.SeverityWarn,
+		logrus.ErrorLevel: log.SeverityError,
+		logrus.FatalLevel: log.SeverityFatal,
+		logrus.PanicLevel: log.SeverityFatal2,
+	} {
+		if got := severity(level); got != want {
+			t.Fatalf("severity(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestHookRedactsForwardedEntries(t *testing.T) {
+	redactor, err := provider.NewRedactor([]string{"password"}, []string{provider.RedactionPatternCNMobile}, provider.RedactionModeMask, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggerProvider, exporter := logtest.NewLoggerProvider(t)
+	logger, buf := newLogger(NewHook(WithLoggerProvider(loggerProvider), WithRedactor(redactor)))
+	logger.WithFields(logrus.Fields{"password": "secret", "phone": "13812345678"}).Info("call +8613812345678")
+
+	r := exporter.Records()[0]
+	if got := r.Body().AsString(); got != "call ****" {
+		t.Fatalf("body = %q", got)
+	}
+	attrs := map[string]log.Value{}
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	if attrs["password"].AsString() != "****" || attrs["phone"].AsString() != "****" {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("13812345678")) {
+		t.Fatalf("entry printed by the logger should not be redacted: %s", buf.String())
+	}
+}
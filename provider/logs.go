@@ -0,0 +1,86 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// newLogExporter 创建日志的Exporter，stdout时打印到标准输出用于调试
+func (c *Config) newLogExporter() (sdklog.Exporter, error) {
+	if c.LogExporterEndpoint == "stdout" {
+		return stdoutlog.New()
+	}
+	creds, err := c.transportCredentials()
+	if err != nil {
+		return nil, err
+	}
+	endpoint, dialOptions, err := c.grpcDialOptions(c.LogExporterEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	secureOption := otlploggrpc.WithTLSCredentials(creds)
+	if c.LogExporterEndpointInsecure {
+		secureOption = otlploggrpc.WithInsecure()
+	}
+	return otlploggrpc.New(context.Background(), otlploggrpc.WithEndpoint(endpoint),
+		secureOption, otlploggrpc.WithHeaders(c.slsHeaders()), otlploggrpc.WithCompressor(gzip.Name),
+		otlploggrpc.WithTimeout(c.ExportTimeout),
+		otlploggrpc.WithRetry(otlploggrpc.RetryConfig{
+			Enabled:         c.RetryEnabled,
+			InitialInterval: c.RetryInitialInterval,
+			MaxInterval:     c.RetryMaxInterval,
+			MaxElapsedTime:  c.RetryMaxElapsedTime,
+		}),
+		otlploggrpc.WithDialOption(dialOptions...))
+}
+
+// initLogger 配置了日志地址时初始化OpenTelemetry Logs，日志与Trace、Metric使用相同的Resource
+func (c *Config) initLogger() error {
+	if c.LogExporterEndpoint == "" {
+		return nil
+	}
+	exporter, err := c.newLogExporter()
+	if err != nil {
+		return err
+	}
+	if stats := c.stats.Load(); stats != nil {
+		exporter = &instrumentedLogExporter{Exporter: exporter, stats: stats.logs}
+	}
+	lp := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+		sdklog.WithResource(c.Resource),
+	)
+	global.SetLoggerProvider(lp)
+	c.loggerProvider = lp
+	c.stop = append(c.stop, func() {
+		lp.Shutdown(context.Background())
+	})
+	return nil
+}
+
+// LoggerProvider returns the logger provider created by Start, it is nil when SLS_OTEL_LOG_ENDPOINT is not configured,
+// log bridges such as provider/slog use it to forward records to the OpenTelemetry Logs pipeline
+// 返回Start创建的LoggerProvider，未配置日志地址时返回nil，日志桥接(例如provider/slog)使用它把日志转发到OpenTelemetry Logs
+func (c *Config) LoggerProvider() log.LoggerProvider {
+	return c.loggerProvider
+}
@@ -0,0 +1,552 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+func resourceMetricsToProto(rm *metricdata.ResourceMetrics) *metricpb.ResourceMetrics {
+	out := &metricpb.ResourceMetrics{}
+	if rm.Resource != nil {
+		out.Resource = &resourcepb.Resource{Attributes: attributesToProto(rm.Resource.Attributes())}
+		out.SchemaUrl = rm.Resource.SchemaURL()
+	}
+	for _, sm := range rm.ScopeMetrics {
+		psm := &metricpb.ScopeMetrics{
+			Scope:     &commonpb.InstrumentationScope{Name: sm.Scope.Name, Version: sm.Scope.Version},
+			SchemaUrl: sm.Scope.SchemaURL,
+		}
+		for _, m := range sm.Metrics {
+			if pm := metricToProto(m); pm != nil {
+				psm.Metrics = append(psm.Metrics, pm)
+			}
+		}
+		out.ScopeMetrics = append(out.ScopeMetrics, psm)
+	}
+	return out
+}
+
+// metricToProto 磁盘缓存中使用OTLP protobuf格式保存Metric，数值类型由数据点和exemplar的AsInt/AsDouble表示
+func metricToProto(m metricdata.Metrics) *metricpb.Metric {
+	pm := &metricpb.Metric{Name: m.Name, Description: m.Description, Unit: m.Unit}
+	switch a := m.Data.(type) {
+	case metricdata.Gauge[int64]:
+		pm.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numberPointsToProto(a.DataPoints)}}
+	case metricdata.Gauge[float64]:
+		pm.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numberPointsToProto(a.DataPoints)}}
+	case metricdata.Sum[int64]:
+		pm.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints:             numberPointsToProto(a.DataPoints),
+			AggregationTemporality: temporalityToProto(a.Temporality),
+			IsMonotonic:            a.IsMonotonic,
+		}}
+	case metricdata.Sum[float64]:
+		pm.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints:             numberPointsToProto(a.DataPoints),
+			AggregationTemporality: temporalityToProto(a.Temporality),
+			IsMonotonic:            a.IsMonotonic,
+		}}
+	case metricdata.Histogram[int64]:
+		pm.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+			DataPoints:             histogramPointsToProto(a.DataPoints),
+			AggregationTemporality: temporalityToProto(a.Temporality),
+		}}
+	case metricdata.Histogram[float64]:
+		pm.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+			DataPoints:             histogramPointsToProto(a.DataPoints),
+			AggregationTemporality: temporalityToProto(a.Temporality),
+		}}
+	case metricdata.ExponentialHistogram[int64]:
+		pm.Data = &metricpb.Metric_ExponentialHistogram{ExponentialHistogram: &metricpb.ExponentialHistogram{
+			DataPoints:             exponentialPointsToProto(a.DataPoints),
+			AggregationTemporality: temporalityToProto(a.Temporality),
+		}}
+	case metricdata.ExponentialHistogram[float64]:
+		pm.Data = &metricpb.Metric_ExponentialHistogram{ExponentialHistogram: &metricpb.ExponentialHistogram{
+			DataPoints:             exponentialPointsToProto(a.DataPoints),
+			AggregationTemporality: temporalityToProto(a.Temporality),
+		}}
+	case metricdata.Summary:
+		pm.Data = &metricpb.Metric_Summary{Summary: &metricpb.Summary{DataPoints: summaryPointsToProto(a.DataPoints)}}
+	default:
+		return nil
+	}
+	return pm
+}
+
+func numberPointsToProto[N int64 | float64](dps []metricdata.DataPoint[N]) []*metricpb.NumberDataPoint {
+	out := make([]*metricpb.NumberDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		pdp := &metricpb.NumberDataPoint{
+			Attributes:        attributesToProto(dp.Attributes.ToSlice()),
+			StartTimeUnixNano: timeToNano(dp.StartTime),
+			TimeUnixNano:      timeToNano(dp.Time),
+			Exemplars:         exemplarsToProto(dp.Exemplars),
+		}
+		switch v := any(dp.Value).(type) {
+		case int64:
+			pdp.Value = &metricpb.NumberDataPoint_AsInt{AsInt: v}
+		case float64:
+			pdp.Value = &metricpb.NumberDataPoint_AsDouble{AsDouble: v}
+		}
+		out = append(out, pdp)
+	}
+	return out
+}
+
+func histogramPointsToProto[N int64 | float64](dps []metricdata.HistogramDataPoint[N]) []*metricpb.HistogramDataPoint {
+	out := make([]*metricpb.HistogramDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		sum := float64(dp.Sum)
+		pdp := &metricpb.HistogramDataPoint{
+			Attributes:        attributesToProto(dp.Attributes.ToSlice()),
+			StartTimeUnixNano: timeToNano(dp.StartTime),
+			TimeUnixNano:      timeToNano(dp.Time),
+			Count:             dp.Count,
+			Sum:               &sum,
+			BucketCounts:      dp.BucketCounts,
+			ExplicitBounds:    dp.Bounds,
+			Exemplars:         exemplarsToProto(dp.Exemplars),
+		}
+		if v, ok := dp.Min.Value(); ok {
+			min := float64(v)
+			pdp.Min = &min
+		}
+		if v, ok := dp.Max.Value(); ok {
+			max := float64(v)
+			pdp.Max = &max
+		}
+		out = append(out, pdp)
+	}
+	return out
+}
+
+func exponentialPointsToProto[N int64 | float64](dps []metricdata.ExponentialHistogramDataPoint[N]) []*metricpb.ExponentialHistogramDataPoint {
+	out := make([]*metricpb.ExponentialHistogramDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		sum := float64(dp.Sum)
+		pdp := &metricpb.ExponentialHistogramDataPoint{
+			Attributes:        attributesToProto(dp.Attributes.ToSlice()),
+			StartTimeUnixNano: timeToNano(dp.StartTime),
+			TimeUnixNano:      timeToNano(dp.Time),
+			Count:             dp.Count,
+			Sum:               &sum,
+			Scale:             dp.Scale,
+			ZeroCount:         dp.ZeroCount,
+			Positive:          &metricpb.ExponentialHistogramDataPoint_Buckets{Offset: dp.PositiveBucket.Offset, BucketCounts: dp.PositiveBucket.Counts},
+			Negative:          &metricpb.ExponentialHistogramDataPoint_Buckets{Offset: dp.NegativeBucket.Offset, BucketCounts: dp.NegativeBucket.Counts},
+			Exemplars:         exemplarsToProto(dp.Exemplars),
+			ZeroThreshold:     dp.ZeroThreshold,
+		}
+		if v, ok := dp.Min.Value(); ok {
+			min := float64(v)
+			pdp.Min = &min
+		}
+		if v, ok := dp.Max.Value(); ok {
+			max := float64(v)
+			pdp.Max = &max
+		}
+		out = append(out, pdp)
+	}
+	return out
+}
+
+func summaryPointsToProto(dps []metricdata.SummaryDataPoint) []*metricpb.SummaryDataPoint {
+	out := make([]*metricpb.SummaryDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		pdp := &metricpb.SummaryDataPoint{
+			Attributes:        attributesToProto(dp.Attributes.ToSlice()),
+			StartTimeUnixNano: timeToNano(dp.StartTime),
+			TimeUnixNano:      timeToNano(dp.Time),
+			Count:             dp.Count,
+			Sum:               dp.Sum,
+		}
+		for _, q := range dp.QuantileValues {
+			pdp.QuantileValues = append(pdp.QuantileValues, &metricpb.SummaryDataPoint_ValueAtQuantile{Quantile: q.Quantile, Value: q.Value})
+		}
+		out = append(out, pdp)
+	}
+	return out
+}
+
+func exemplarsToProto[N int64 | float64](exemplars []metricdata.Exemplar[N]) []*metricpb.Exemplar {
+	if len(exemplars) == 0 {
+		return nil
+	}
+	out := make([]*metricpb.Exemplar, 0, len(exemplars))
+	for _, e := range exemplars {
+		pe := &metricpb.Exemplar{
+			FilteredAttributes: attributesToProto(e.FilteredAttributes),
+			TimeUnixNano:       timeToNano(e.Time),
+			SpanId:             e.SpanID,
+			TraceId:            e.TraceID,
+		}
+		switch v := any(e.Value).(type) {
+		case int64:
+			pe.Value = &metricpb.Exemplar_AsInt{AsInt: v}
+		case float64:
+			pe.Value = &metricpb.Exemplar_AsDouble{AsDouble: v}
+		}
+		out = append(out, pe)
+	}
+	return out
+}
+
+func temporalityToProto(t metricdata.Temporality) metricpb.AggregationTemporality {
+	switch t {
+	case metricdata.DeltaTemporality:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	case metricdata.CumulativeTemporality:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	}
+	return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED
+}
+
+func timeToNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
+func attributesToProto(attrs []attribute.KeyValue) []*commonpb.KeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		out = append(out, &commonpb.KeyValue{Key: string(kv.Key), Value: attributeValueToProto(kv.Value)})
+	}
+	return out
+}
+
+func attributeValueToProto(v attribute.Value) *commonpb.AnyValue {
+	av := &commonpb.AnyValue{}
+	switch v.Type() {
+	case attribute.BOOL:
+		av.Value = &commonpb.AnyValue_BoolValue{BoolValue: v.AsBool()}
+	case attribute.INT64:
+		av.Value = &commonpb.AnyValue_IntValue{IntValue: v.AsInt64()}
+	case attribute.FLOAT64:
+		av.Value = &commonpb.AnyValue_DoubleValue{DoubleValue: v.AsFloat64()}
+	case attribute.STRING:
+		av.Value = &commonpb.AnyValue_StringValue{StringValue: v.AsString()}
+	case attribute.BOOLSLICE:
+		values := []*commonpb.AnyValue{}
+		for _, b := range v.AsBoolSlice() {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: b}})
+		}
+		av.Value = &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}
+	case attribute.INT64SLICE:
+		values := []*commonpb.AnyValue{}
+		for _, i := range v.AsInt64Slice() {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: i}})
+		}
+		av.Value = &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}
+	case attribute.FLOAT64SLICE:
+		values := []*commonpb.AnyValue{}
+		for _, f := range v.AsFloat64Slice() {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: f}})
+		}
+		av.Value = &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}
+	case attribute.STRINGSLICE:
+		values := []*commonpb.AnyValue{}
+		for _, s := range v.AsStringSlice() {
+			values = append(values, &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}})
+		}
+		av.Value = &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}
+	default:
+		av.Value = &commonpb.AnyValue_StringValue{StringValue: v.Emit()}
+	}
+	return av
+}
+
+func resourceMetricsFromProto(prm *metricpb.ResourceMetrics) *metricdata.ResourceMetrics {
+	rm := &metricdata.ResourceMetrics{
+		Resource: resource.NewWithAttributes(prm.SchemaUrl, attributesFromProto(prm.GetResource().GetAttributes())...),
+	}
+	for _, psm := range prm.ScopeMetrics {
+		sm := metricdata.ScopeMetrics{
+			Scope: instrumentation.Scope{
+				Name:      psm.GetScope().GetName(),
+				Version:   psm.GetScope().GetVersion(),
+				SchemaURL: psm.SchemaUrl,
+			},
+		}
+		for _, pm := range psm.Metrics {
+			if m, ok := metricFromProto(pm); ok {
+				sm.Metrics = append(sm.Metrics, m)
+			}
+		}
+		rm.ScopeMetrics = append(rm.ScopeMetrics, sm)
+	}
+	return rm
+}
+
+// metricFromProto 根据数值类型还原metricdata，Histogram的Sum、Min和Max在OTLP中总是double，
+// 只有exemplar区分数值类型，没有数据点或exemplar时还原为float64，再次导出的OTLP数据相同
+func metricFromProto(pm *metricpb.Metric) (metricdata.Metrics, bool) {
+	m := metricdata.Metrics{Name: pm.Name, Description: pm.Description, Unit: pm.Unit}
+	switch d := pm.Data.(type) {
+	case *metricpb.Metric_Gauge:
+		if isIntNumber(d.Gauge.DataPoints) {
+			m.Data = metricdata.Gauge[int64]{DataPoints: numberPointsFromProto[int64](d.Gauge.DataPoints)}
+		} else {
+			m.Data = metricdata.Gauge[float64]{DataPoints: numberPointsFromProto[float64](d.Gauge.DataPoints)}
+		}
+	case *metricpb.Metric_Sum:
+		temporality := temporalityFromProto(d.Sum.AggregationTemporality)
+		if isIntNumber(d.Sum.DataPoints) {
+			m.Data = metricdata.Sum[int64]{DataPoints: numberPointsFromProto[int64](d.Sum.DataPoints), Temporality: temporality, IsMonotonic: d.Sum.IsMonotonic}
+		} else {
+			m.Data = metricdata.Sum[float64]{DataPoints: numberPointsFromProto[float64](d.Sum.DataPoints), Temporality: temporality, IsMonotonic: d.Sum.IsMonotonic}
+		}
+	case *metricpb.Metric_Histogram:
+		temporality := temporalityFromProto(d.Histogram.AggregationTemporality)
+		var exemplars []*metricpb.Exemplar
+		for _, pdp := range d.Histogram.DataPoints {
+			exemplars = append(exemplars, pdp.Exemplars...)
+		}
+		if isIntExemplar(exemplars) {
+			m.Data = metricdata.Histogram[int64]{DataPoints: histogramPointsFromProto[int64](d.Histogram.DataPoints), Temporality: temporality}
+		} else {
+			m.Data = metricdata.Histogram[float64]{DataPoints: histogramPointsFromProto[float64](d.Histogram.DataPoints), Temporality: temporality}
+		}
+	case *metricpb.Metric_ExponentialHistogram:
+		temporality := temporalityFromProto(d.ExponentialHistogram.AggregationTemporality)
+		var exemplars []*metricpb.Exemplar
+		for _, pdp := range d.ExponentialHistogram.DataPoints {
+			exemplars = append(exemplars, pdp.Exemplars...)
+		}
+		if isIntExemplar(exemplars) {
+			m.Data = metricdata.ExponentialHistogram[int64]{DataPoints: exponentialPointsFromProto[int64](d.ExponentialHistogram.DataPoints), Temporality: temporality}
+		} else {
+			m.Data = metricdata.ExponentialHistogram[float64]{DataPoints: exponentialPointsFromProto[float64](d.ExponentialHistogram.DataPoints), Temporality: temporality}
+		}
+	case *metricpb.Metric_Summary:
+		m.Data = metricdata.Summary{DataPoints: summaryPointsFromProto(d.Summary.DataPoints)}
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// isIntNumber 同一个指标的数据点数值类型相同，由第一个数据点决定
+func isIntNumber(pdps []*metricpb.NumberDataPoint) bool {
+	if len(pdps) == 0 {
+		return false
+	}
+	_, ok := pdps[0].Value.(*metricpb.NumberDataPoint_AsInt)
+	return ok
+}
+
+func isIntExemplar(pes []*metricpb.Exemplar) bool {
+	if len(pes) == 0 {
+		return false
+	}
+	_, ok := pes[0].Value.(*metricpb.Exemplar_AsInt)
+	return ok
+}
+
+func numberPointsFromProto[N int64 | float64](pdps []*metricpb.NumberDataPoint) []metricdata.DataPoint[N] {
+	out := make([]metricdata.DataPoint[N], 0, len(pdps))
+	for _, pdp := range pdps {
+		dp := metricdata.DataPoint[N]{
+			Attributes: attribute.NewSet(attributesFromProto(pdp.Attributes)...),
+			StartTime:  nanoToTime(pdp.StartTimeUnixNano),
+			Time:       nanoToTime(pdp.TimeUnixNano),
+			Exemplars:  exemplarsFromProto[N](pdp.Exemplars),
+		}
+		switch v := pdp.Value.(type) {
+		case *metricpb.NumberDataPoint_AsInt:
+			dp.Value = N(v.AsInt)
+		case *metricpb.NumberDataPoint_AsDouble:
+			dp.Value = N(v.AsDouble)
+		}
+		out = append(out, dp)
+	}
+	return out
+}
+
+func histogramPointsFromProto[N int64 | float64](pdps []*metricpb.HistogramDataPoint) []metricdata.HistogramDataPoint[N] {
+	out := make([]metricdata.HistogramDataPoint[N], 0, len(pdps))
+	for _, pdp := range pdps {
+		dp := metricdata.HistogramDataPoint[N]{
+			Attributes:   attribute.NewSet(attributesFromProto(pdp.Attributes)...),
+			StartTime:    nanoToTime(pdp.StartTimeUnixNano),
+			Time:         nanoToTime(pdp.TimeUnixNano),
+			Count:        pdp.Count,
+			Bounds:       pdp.ExplicitBounds,
+			BucketCounts: pdp.BucketCounts,
+			Sum:          N(pdp.GetSum()),
+			Exemplars:    exemplarsFromProto[N](pdp.Exemplars),
+		}
+		if pdp.Min != nil {
+			dp.Min = metricdata.NewExtrema(N(*pdp.Min))
+		}
+		if pdp.Max != nil {
+			dp.Max = metricdata.NewExtrema(N(*pdp.Max))
+		}
+		out = append(out, dp)
+	}
+	return out
+}
+
+func exponentialPointsFromProto[N int64 | float64](pdps []*metricpb.ExponentialHistogramDataPoint) []metricdata.ExponentialHistogramDataPoint[N] {
+	out := make([]metricdata.ExponentialHistogramDataPoint[N], 0, len(pdps))
+	for _, pdp := range pdps {
+		dp := metricdata.ExponentialHistogramDataPoint[N]{
+			Attributes:     attribute.NewSet(attributesFromProto(pdp.Attributes)...),
+			StartTime:      nanoToTime(pdp.StartTimeUnixNano),
+			Time:           nanoToTime(pdp.TimeUnixNano),
+			Count:          pdp.Count,
+			Sum:            N(pdp.GetSum()),
+			Scale:          pdp.Scale,
+			ZeroCount:      pdp.ZeroCount,
+			PositiveBucket: metricdata.ExponentialBucket{Offset: pdp.GetPositive().GetOffset(), Counts: pdp.GetPositive().GetBucketCounts()},
+			NegativeBucket: metricdata.ExponentialBucket{Offset: pdp.GetNegative().GetOffset(), Counts: pdp.GetNegative().GetBucketCounts()},
+			ZeroThreshold:  pdp.ZeroThreshold,
+			Exemplars:      exemplarsFromProto[N](pdp.Exemplars),
+		}
+		if pdp.Min != nil {
+			dp.Min = metricdata.NewExtrema(N(*pdp.Min))
+		}
+		if pdp.Max != nil {
+			dp.Max = metricdata.NewExtrema(N(*pdp.Max))
+		}
+		out = append(out, dp)
+	}
+	return out
+}
+
+func summaryPointsFromProto(pdps []*metricpb.SummaryDataPoint) []metricdata.SummaryDataPoint {
+	out := make([]metricdata.SummaryDataPoint, 0, len(pdps))
+	for _, pdp := range pdps {
+		dp := metricdata.SummaryDataPoint{
+			Attributes: attribute.NewSet(attributesFromProto(pdp.Attributes)...),
+			StartTime:  nanoToTime(pdp.StartTimeUnixNano),
+			Time:       nanoToTime(pdp.TimeUnixNano),
+			Count:      pdp.Count,
+			Sum:        pdp.Sum,
+		}
+		for _, q := range pdp.QuantileValues {
+			dp.QuantileValues = append(dp.QuantileValues, metricdata.QuantileValue{Quantile: q.Quantile, Value: q.Value})
+		}
+		out = append(out, dp)
+	}
+	return out
+}
+
+func exemplarsFromProto[N int64 | float64](pes []*metricpb.Exemplar) []metricdata.Exemplar[N] {
+	if len(pes) == 0 {
+		return nil
+	}
+	out := make([]metricdata.Exemplar[N], 0, len(pes))
+	for _, pe := range pes {
+		e := metricdata.Exemplar[N]{
+			FilteredAttributes: attributesFromProto(pe.FilteredAttributes),
+			Time:               nanoToTime(pe.TimeUnixNano),
+			SpanID:             pe.SpanId,
+			TraceID:            pe.TraceId,
+		}
+		switch v := pe.Value.(type) {
+		case *metricpb.Exemplar_AsInt:
+			e.Value = N(v.AsInt)
+		case *metricpb.Exemplar_AsDouble:
+			e.Value = N(v.AsDouble)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func temporalityFromProto(t metricpb.AggregationTemporality) metricdata.Temporality {
+	switch t {
+	case metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA:
+		return metricdata.DeltaTemporality
+	case metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE:
+		return metricdata.CumulativeTemporality
+	}
+	return metricdata.Temporality(0)
+}
+
+func nanoToTime(nano uint64) time.Time {
+	if nano == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(nano))
+}
+
+func attributesFromProto(kvs []*commonpb.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		out = append(out, attribute.KeyValue{Key: attribute.Key(kv.Key), Value: attributeValueFromProto(kv.Value)})
+	}
+	return out
+}
+
+func attributeValueFromProto(av *commonpb.AnyValue) attribute.Value {
+	switch v := av.GetValue().(type) {
+	case *commonpb.AnyValue_BoolValue:
+		return attribute.BoolValue(v.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return attribute.Int64Value(v.IntValue)
+	case *commonpb.AnyValue_DoubleValue:
+		return attribute.Float64Value(v.DoubleValue)
+	case *commonpb.AnyValue_StringValue:
+		return attribute.StringValue(v.StringValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := v.ArrayValue.GetValues()
+		if len(values) == 0 {
+			return attribute.StringSliceValue(nil)
+		}
+		switch values[0].GetValue().(type) {
+		case *commonpb.AnyValue_BoolValue:
+			out := make([]bool, 0, len(values))
+			for _, value := range values {
+				out = append(out, value.GetBoolValue())
+			}
+			return attribute.BoolSliceValue(out)
+		case *commonpb.AnyValue_IntValue:
+			out := make([]int64, 0, len(values))
+			for _, value := range values {
+				out = append(out, value.GetIntValue())
+			}
+			return attribute.Int64SliceValue(out)
+		case *commonpb.AnyValue_DoubleValue:
+			out := make([]float64, 0, len(values))
+			for _, value := range values {
+				out = append(out, value.GetDoubleValue())
+			}
+			return attribute.Float64SliceValue(out)
+		default:
+			out := make([]string, 0, len(values))
+			for _, value := range values {
+				out = append(out, value.GetStringValue())
+			}
+			return attribute.StringSliceValue(out)
+		}
+	}
+	return attribute.StringValue("")
+}
@@ -0,0 +1,180 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.opentelemetry.io/otel/sdk/resource"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	codecStart = time.Unix(1700000000, 0)
+	codecEnd   = codecStart.Add(30 * time.Second)
+	codecAttrs = attribute.NewSet(
+		attribute.String("host", "a"),
+		attribute.Int("port", 80),
+		attribute.Bool("tls", true),
+		attribute.Float64("ratio", 0.5),
+		attribute.StringSlice("tags", []string{"x", "y"}),
+		attribute.Int64Slice("ids", []int64{1, 2}),
+	)
+	codecTraceID = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	codecSpanID  = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+)
+
+func codecExemplars[N int64 | float64](value N) []metricdata.Exemplar[N] {
+	return []metricdata.Exemplar[N]{{
+		FilteredAttributes: []attribute.KeyValue{attribute.String("user", "u1")},
+		Time:               codecEnd,
+		Value:              value,
+		SpanID:             codecSpanID,
+		TraceID:            codecTraceID,
+	}}
+}
+
+func codecNumberPoints[N int64 | float64](value N) []metricdata.DataPoint[N] {
+	return []metricdata.DataPoint[N]{
+		{Attributes: codecAttrs, StartTime: codecStart, Time: codecEnd, Value: value, Exemplars: codecExemplars(value)},
+		{Attributes: attribute.NewSet(), StartTime: codecStart, Time: codecEnd, Value: value + 1},
+	}
+}
+
+func codecHistogramPoints[N int64 | float64](exemplar N) []metricdata.HistogramDataPoint[N] {
+	return []metricdata.HistogramDataPoint[N]{{
+		Attributes:   codecAttrs,
+		StartTime:    codecStart,
+		Time:         codecEnd,
+		Count:        6,
+		Bounds:       []float64{0, 5, 10},
+		BucketCounts: []uint64{1, 2, 3, 0},
+		Min:          metricdata.NewExtrema[N](1),
+		Max:          metricdata.NewExtrema[N](9),
+		Sum:          30,
+		Exemplars:    codecExemplars(exemplar),
+	}}
+}
+
+func codecExponentialPoints[N int64 | float64](exemplar N) []metricdata.ExponentialHistogramDataPoint[N] {
+	return []metricdata.ExponentialHistogramDataPoint[N]{{
+		Attributes:     codecAttrs,
+		StartTime:      codecStart,
+		Time:           codecEnd,
+		Count:          7,
+		Min:            metricdata.NewExtrema[N](1),
+		Max:            metricdata.NewExtrema[N](8),
+		Sum:            25,
+		Scale:          2,
+		ZeroCount:      1,
+		PositiveBucket: metricdata.ExponentialBucket{Offset: 1, Counts: []uint64{1, 2}},
+		NegativeBucket: metricdata.ExponentialBucket{Offset: -1, Counts: []uint64{3}},
+		ZeroThreshold:  0.001,
+		Exemplars:      codecExemplars(exemplar),
+	}}
+}
+
+// roundTrip 模拟写入磁盘缓存再读取
+func roundTrip(t *testing.T, rm *metricdata.ResourceMetrics) *metricdata.ResourceMetrics {
+	t.Helper()
+	payload, err := proto.Marshal(&metricpb.MetricsData{ResourceMetrics: []*metricpb.ResourceMetrics{resourceMetricsToProto(rm)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data metricpb.MetricsData
+	if err := proto.Unmarshal(payload, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.ResourceMetrics) != 1 {
+		t.Fatalf("decoded %d resource metrics", len(data.ResourceMetrics))
+	}
+	return resourceMetricsFromProto(data.ResourceMetrics[0])
+}
+
+func TestMetricCodecRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data metricdata.Aggregation
+	}{
+		{"int64 gauge", metricdata.Gauge[int64]{DataPoints: codecNumberPoints[int64](3)}},
+		{"float64 gauge", metricdata.Gauge[float64]{DataPoints: codecNumberPoints(2.5)}},
+		{"int64 delta sum", metricdata.Sum[int64]{DataPoints: codecNumberPoints[int64](4), Temporality: metricdata.DeltaTemporality, IsMonotonic: true}},
+		{"float64 cumulative sum", metricdata.Sum[float64]{DataPoints: codecNumberPoints(1.5), Temporality: metricdata.CumulativeTemporality}},
+		{"int64 histogram", metricdata.Histogram[int64]{DataPoints: codecHistogramPoints[int64](4), Temporality: metricdata.DeltaTemporality}},
+		{"float64 histogram", metricdata.Histogram[float64]{DataPoints: codecHistogramPoints(4.5), Temporality: metricdata.CumulativeTemporality}},
+		{"int64 exponential histogram", metricdata.ExponentialHistogram[int64]{DataPoints: codecExponentialPoints[int64](3), Temporality: metricdata.DeltaTemporality}},
+		{"float64 exponential histogram", metricdata.ExponentialHistogram[float64]{DataPoints: codecExponentialPoints(3.5), Temporality: metricdata.CumulativeTemporality}},
+		{"summary", metricdata.Summary{DataPoints: []metricdata.SummaryDataPoint{{
+			Attributes:     codecAttrs,
+			StartTime:      codecStart,
+			Time:           codecEnd,
+			Count:          4,
+			Sum:            10,
+			QuantileValues: []metricdata.QuantileValue{{Quantile: 0.5, Value: 2}, {Quantile: 0.99, Value: 4}},
+		}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := metricdata.ResourceMetrics{
+				Resource: resource.NewWithAttributes("https://opentelemetry.io/schemas/1.7.0", attribute.String("service.name", "test")),
+				ScopeMetrics: []metricdata.ScopeMetrics{{
+					Scope:   instrumentation.Scope{Name: "scope", Version: "v1", SchemaURL: "https://opentelemetry.io/schemas/1.7.0"},
+					Metrics: []metricdata.Metrics{{Name: "metric", Description: "description", Unit: "ms", Data: tt.data}},
+				}},
+			}
+			got := roundTrip(t, &want)
+			metricdatatest.AssertEqual(t, want, *got)
+		})
+	}
+}
+
+// 没有exemplar时Histogram的数值类型无法还原，但再次导出的OTLP数据必须相同
+func TestMetricCodecHistogramWithoutExemplars(t *testing.T) {
+	points := codecHistogramPoints[int64](0)
+	points[0].Exemplars = nil
+	rm := &metricdata.ResourceMetrics{
+		Resource: resource.Empty(),
+		ScopeMetrics: []metricdata.ScopeMetrics{{Metrics: []metricdata.Metrics{
+			{Name: "histogram", Data: metricdata.Histogram[int64]{DataPoints: points, Temporality: metricdata.DeltaTemporality}},
+			{Name: "empty gauge", Data: metricdata.Gauge[int64]{}},
+		}}},
+	}
+	want := resourceMetricsToProto(rm)
+	if got := resourceMetricsToProto(roundTrip(t, rm)); !proto.Equal(want, got) {
+		t.Fatalf("round trip changed the OTLP data\nwant %v\ngot  %v", want, got)
+	}
+}
+
+func TestMetricCodecDoesNotAddMetadata(t *testing.T) {
+	rm := &metricdata.ResourceMetrics{
+		Resource: resource.Empty(),
+		ScopeMetrics: []metricdata.ScopeMetrics{{Metrics: []metricdata.Metrics{
+			{Name: "gauge", Data: metricdata.Gauge[int64]{DataPoints: codecNumberPoints[int64](1)}},
+		}}},
+	}
+	for _, sm := range resourceMetricsToProto(rm).ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if len(m.Metadata) != 0 {
+				t.Fatalf("metric %q has metadata %v", m.Name, m.Metadata)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+const (
+	// prometheusEndpoint MetricExporterEndpoint为prometheus时，不再推送指标，改为提供Prometheus抓取接口
+	prometheusEndpoint = "prometheus"
+	prometheusPath     = "/metrics"
+)
+
+// newPrometheusReader 创建Prometheus Reader并在PrometheusListenAddr上提供/metrics接口，Resource以target_info指标暴露
+func (c *Config) newPrometheusReader() (metric.Reader, func(), error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprometheus.New(
+		otelprometheus.WithRegisterer(registry),
+		otelprometheus.WithAggregationSelector(c.aggregationSelector))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ln, err := net.Listen("tcp", c.PrometheusListenAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(prometheusPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorLog: promErrorLogger{}}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			otel.Handle(err)
+		}
+	}()
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}
+	return exporter, stop, nil
+}
+
+// validatePrometheus 检查Prometheus Exporter不支持的配置，Exporter只支持累计值，并且会丢弃指数直方图
+func (c *Config) validatePrometheus() error {
+	if c.MetricExporterEndpoint != prometheusEndpoint {
+		return nil
+	}
+	if c.MetricTemporality != TemporalityCumulative {
+		return errors.New("prometheus exporter only supports cumulative temporality")
+	}
+	if c.MetricHistogramAggregation == AggregationBase2ExponentialBucketHistogram {
+		return errors.New("prometheus exporter does not support exponential histograms")
+	}
+	for i, v := range c.MetricViews {
+		if v.Aggregation == AggregationBase2ExponentialBucketHistogram {
+			return fmt.Errorf("invalid metric view #%d: prometheus exporter does not support exponential histograms", i)
+		}
+	}
+	return nil
+}
+
+// promErrorLogger 把抓取时的错误交给ErrorHandler处理
+type promErrorLogger struct{}
+
+func (promErrorLogger) Println(v ...interface{}) {
+	otel.Handle(fmt.Errorf("prometheus exporter: %s", fmt.Sprint(v...)))
+}
@@ -0,0 +1,99 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestPrometheusConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "default", opts: nil},
+		{name: "delta temporality", opts: []Option{WithMetricTemporality(TemporalityDelta)}, wantErr: true},
+		{name: "exponential histogram", opts: []Option{WithExponentialHistogram(0, 0)}, wantErr: true},
+		{name: "exponential view", opts: []Option{WithMetricViews(MetricView{
+			InstrumentName: "latency", Aggregation: AggregationBase2ExponentialBucketHistogram})}, wantErr: true},
+		{name: "explicit bucket view", opts: []Option{WithMetricViews(MetricView{
+			InstrumentName: "latency", Boundaries: []float64{1, 10}})}},
+		{name: "trace endpoint", opts: []Option{WithTraceExporterEndpoint(prometheusEndpoint)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithServiceName("test"), WithTraceExporterEndpoint(""), WithPrometheusExporter(":9464")}, tt.opts...)
+			_, err := NewConfig(opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConfig error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	// 不使用Prometheus时可以配置指数直方图
+	if _, err := NewConfig(WithServiceName("test"), WithExponentialHistogram(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrometheusReader(t *testing.T) {
+	c, err := NewConfig(WithServiceName("test"), WithTraceExporterEndpoint(""), WithPrometheusExporter(freeAddr(t)),
+		WithMetricViews(MetricView{InstrumentName: "latency", Boundaries: []float64{1, 10}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, stop, err := c.newPrometheusReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader), metric.WithView(c.metricViews()...),
+		metric.WithResource(resource.NewSchemaless(attribute.String("service.name", "test"))))
+	defer meterProvider.Shutdown(context.Background())
+
+	meter := meterProvider.Meter("test")
+	counter, _ := meter.Int64Counter("requests")
+	counter.Add(context.Background(), 3, otelmetric.WithAttributes(attribute.String("path", "/users")))
+	histogram, _ := meter.Float64Histogram("latency")
+	histogram.Record(context.Background(), 5)
+
+	resp, err := http.Get("http://" + c.PrometheusListenAddr + prometheusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`requests_total{otel_scope_name="test",otel_scope_version="",path="/users"} 3`,
+		`latency_bucket{otel_scope_name="test",otel_scope_version="",le="10"} 1`,
+		`target_info{service_name="test"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+}
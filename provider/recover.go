@@ -0,0 +1,109 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// panicFlushTimeout 重新panic之前等待数据发送的最长时间
+const panicFlushTimeout = 5 * time.Second
+
+// flusher 支持ForceFlush的TracerProvider和LoggerProvider
+type flusher interface {
+	ForceFlush(ctx context.Context) error
+}
+
+// RecoverAndRecord records a panic on the span in ctx and panics again with the same value, it must be deferred directly:
+//
+//	defer provider.RecoverAndRecord(ctx)
+//
+// the panic is recorded as an exception event with exception.type, exception.message and exception.stacktrace
+// and the span status is set to Error, the span is left to be ended by its owner, then finished spans and logs
+// are flushed synchronously so that they are not lost if the panic terminates the process, the panic is also
+// reported to OpenTelemetry Logs when SLS_OTEL_LOG_ENDPOINT is configured, http.ErrAbortHandler is not recorded
+// 记录panic并重新panic，需要直接defer调用。panic作为exception事件记录到ctx中的Span上，Span状态设置为Error，Span仍由创建方结束，
+// 重新panic之前同步发送已结束的Span和日志，避免进程退出时丢失，配置了日志地址时同时上报一条日志，http.ErrAbortHandler不会被记录
+func RecoverAndRecord(ctx context.Context) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v != http.ErrAbortHandler {
+		recordPanic(ctx, v, string(debug.Stack()))
+	}
+	panic(v)
+}
+
+// RecoverMiddleware returns a handler that records panics of next with RecoverAndRecord, it should be installed
+// inside the otelhttp or otelmux middleware so that the request span is available in the request context
+// 返回记录panic的Handler，需要放在otelhttp或otelmux中间件之后，使请求的Context中带有Span
+func RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer RecoverAndRecord(r.Context())
+		next.ServeHTTP(w, r)
+	})
+}
+
+func recordPanic(ctx context.Context, v any, stack string) {
+	message := fmt.Sprint(v)
+	attrs := []attribute.KeyValue{
+		semconv.ExceptionTypeKey.String(fmt.Sprintf("%T", v)),
+		semconv.ExceptionMessageKey.String(message),
+		semconv.ExceptionStacktraceKey.String(stack),
+		semconv.ExceptionEscapedKey.Bool(true),
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, message)
+
+	var record log.Record
+	record.SetTimestamp(time.Now())
+	record.SetSeverity(log.SeverityError)
+	record.SetSeverityText("PANIC")
+	record.SetBody(log.StringValue("panic: " + message))
+	record.AddAttributes(
+		log.String(string(semconv.ExceptionTypeKey), fmt.Sprintf("%T", v)),
+		log.String(string(semconv.ExceptionMessageKey), message),
+		log.String(string(semconv.ExceptionStacktraceKey), stack),
+	)
+	global.GetLoggerProvider().Logger(instrumentationName).Emit(ctx, record)
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), panicFlushTimeout)
+	defer cancel()
+	if tp, ok := otel.GetTracerProvider().(flusher); ok {
+		if err := tp.ForceFlush(flushCtx); err != nil {
+			otel.Handle(err)
+		}
+	}
+	if lp, ok := global.GetLoggerProvider().(flusher); ok {
+		if err := lp.ForceFlush(flushCtx); err != nil {
+			otel.Handle(err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/internal/logtest"
+)
+
+// startTestPanicRecording 安装Trace和使用BatchProcessor的全局LoggerProvider，只有ForceFlush后才能导出数据
+func startTestPanicRecording(t *testing.T) (*recordingSpanExporter, *logtest.RecordingExporter) {
+	t.Helper()
+	spans := &recordingSpanExporter{}
+	startTestTracer(t, spans, WithBatchSpanProcessor(0, 0, time.Hour, 0))
+	logs := &logtest.RecordingExporter{}
+	loggerProvider := sdklog.NewLoggerProvider(sdklog.WithProcessor(
+		sdklog.NewBatchProcessor(logs, sdklog.WithExportInterval(time.Hour))))
+	previous := global.GetLoggerProvider()
+	global.SetLoggerProvider(loggerProvider)
+	t.Cleanup(func() {
+		global.SetLoggerProvider(previous)
+		loggerProvider.Shutdown(context.Background())
+	})
+	return spans, logs
+}
+
+// recoverValue 返回f重新panic的值
+func recoverValue(f func()) (v any) {
+	defer func() { v = recover() }()
+	f()
+	return nil
+}
+
+func findSpan(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, s := range spans {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func hasStacktraceEvent(s sdktrace.ReadOnlySpan) bool {
+	for _, event := range s.Events() {
+		for _, kv := range event.Attributes {
+			if event.Name == semconv.ExceptionEventName && kv.Key == semconv.ExceptionStacktraceKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestRecoverAndRecord(t *testing.T) {
+	spans, logs := startTestPanicRecording(t)
+	tracer := otel.Tracer("test")
+	ctx, span := tracer.Start(context.Background(), "handler")
+
+	v := recoverValue(func() {
+		defer RecoverAndRecord(ctx)
+		_, child := tracer.Start(ctx, "child")
+		child.End()
+		panic("boom")
+	})
+	if v != "boom" {
+		t.Fatalf("re-panicked with %v, want boom", v)
+	}
+
+	// 重新panic之前已同步发送结束的Span和日志，ctx中的Span仍未结束
+	if findSpan(spans.spans(), "child") == nil {
+		t.Fatal("finished spans were not flushed before re-panicking")
+	}
+	if findSpan(spans.spans(), "handler") != nil {
+		t.Fatal("the span in ctx should not be ended by RecoverAndRecord")
+	}
+	records := logs.Records()
+	if len(records) != 1 {
+		t.Fatalf("got %d logs, want 1", len(records))
+	}
+	record := records[0]
+	if record.Severity() != log.SeverityError || record.Body().AsString() != "panic: boom" {
+		t.Fatalf("unexpected log %v %q", record.Severity(), record.Body().AsString())
+	}
+	if record.SpanID() != span.SpanContext().SpanID() {
+		t.Fatal("the log is not correlated with the span")
+	}
+
+	span.End()
+	forceFlush(t)
+	handler := findSpan(spans.spans(), "handler")
+	if handler == nil {
+		t.Fatal("handler span not exported")
+	}
+	if handler.Status().Code != codes.Error || handler.Status().Description != "boom" {
+		t.Fatalf("status = %+v, want error", handler.Status())
+	}
+	events := handler.Events()
+	if len(events) != 1 || events[0].Name != semconv.ExceptionEventName {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	attrs := map[string]string{}
+	for _, kv := range events[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs[string(semconv.ExceptionTypeKey)] != "string" || attrs[string(semconv.ExceptionMessageKey)] != "boom" ||
+		!strings.Contains(attrs[string(semconv.ExceptionStacktraceKey)], "TestRecoverAndRecord") {
+		t.Fatalf("unexpected exception attributes %v", attrs)
+	}
+}
+
+func TestRecoverAndRecordWithoutPanic(t *testing.T) {
+	spans, logs := startTestPanicRecording(t)
+	ctx, span := otel.Tracer("test").Start(context.Background(), "handler")
+	func() {
+		defer RecoverAndRecord(ctx)
+	}()
+	span.End()
+	forceFlush(t)
+	if s := findSpan(spans.spans(), "handler"); s == nil || len(s.Events()) != 0 || s.Status().Code != codes.Unset {
+		t.Fatal("span changed without a panic")
+	}
+	if len(logs.Records()) != 0 {
+		t.Fatal("log emitted without a panic")
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	spans, logs := startTestPanicRecording(t)
+	// 模拟otelhttp中间件，请求的Span由外层创建并结束
+	handler := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := otel.Tracer("test").Start(r.Context(), "GET /")
+			defer span.End()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	tests := []struct {
+		name      string
+		value     any
+		wantEvent bool
+	}{
+		{"panic", "boom", true},
+		{"abort handler", http.ErrAbortHandler, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans.batches = nil
+			logs.Reset()
+			h := handler(RecoverMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tt.value)
+			})))
+			v := recoverValue(func() {
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			})
+			if v != tt.value {
+				t.Fatalf("re-panicked with %v, want %v", v, tt.value)
+			}
+			forceFlush(t)
+			s := findSpan(spans.spans(), "GET /")
+			if s == nil {
+				t.Fatal("request span not exported")
+			}
+			// 在panic过程中结束的Span会由SDK再记录一个不带堆栈的exception事件
+			if recorded := hasStacktraceEvent(s) && s.Status().Code == codes.Error; recorded != tt.wantEvent {
+				t.Fatalf("events = %+v, status = %+v", s.Events(), s.Status())
+			}
+			if recorded := len(logs.Records()) == 1; recorded != tt.wantEvent {
+				t.Fatalf("got %d logs", len(logs.Records()))
+			}
+		})
+	}
+}
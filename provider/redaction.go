@@ -0,0 +1,302 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// redaction modes
+const (
+	// RedactionModeMask 把敏感内容替换为****
+	RedactionModeMask = "mask"
+	// RedactionModeHash 使用配置的密钥把敏感内容替换为HMAC-SHA256，相同的值脱敏后仍然相同，便于关联查询，
+	// 没有密钥无法通过穷举手机号、身份证号等取值范围较小的内容还原
+	RedactionModeHash = "hash"
+)
+
+const redactionMask = "****"
+
+// builtin redaction patterns
+const (
+	RedactionPatternCNMobile    = "cn_mobile"
+	RedactionPatternCNIDCard    = "cn_id_card"
+	RedactionPatternEmail       = "email"
+	RedactionPatternBearerToken = "bearer_token"
+)
+
+var builtinRedactionPatterns = map[string]string{
+	RedactionPatternCNMobile:    `(?:(?:\+|00)?86[- ]?)?1[3-9]\d{9}`,
+	RedactionPatternCNIDCard:    `[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]`,
+	RedactionPatternEmail:       `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	RedactionPatternBearerToken: `(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`,
+}
+
+// digitBoundaryPatterns 匹配内容前后不能是数字的内置规则，避免脱敏更长数字中的一部分，
+// Go的正则表达式不支持断言，并且\b在+86前缀和中文等字符旁边不成立，所以在匹配之后检查
+var digitBoundaryPatterns = map[string]bool{
+	RedactionPatternCNMobile: true,
+	RedactionPatternCNIDCard: true,
+}
+
+type redactionPattern struct {
+	re            *regexp.Regexp
+	digitBoundary bool
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// redact 替换s中匹配的内容，digitBoundary为true时跳过前后紧邻数字的匹配
+func (p *redactionPattern) redact(s string, replace func(string) string) string {
+	if !p.digitBoundary {
+		return p.re.ReplaceAllStringFunc(s, replace)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range p.re.FindAllStringIndex(s, -1) {
+		if (m[0] > 0 && isDigit(s[m[0]-1])) || (m[1] < len(s) && isDigit(s[m[1]])) {
+			continue
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(replace(s[m[0]:m[1]]))
+		last = m[1]
+	}
+	if b.Len() == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Redactor masks sensitive attribute values, it is used by the span redaction processor
+// and by the slog, zap and logrus bridges for forwarded log records
+// 敏感信息脱敏，按属性名整体脱敏或按正则表达式脱敏匹配的内容，可用于Span和日志
+type Redactor struct {
+	keys     map[string]struct{}
+	patterns []redactionPattern
+	hashKey  []byte
+}
+
+// NewRedactor creates a Redactor, keys are attribute keys (case-insensitive) whose values are redacted entirely,
+// patterns are builtin pattern names (cn_mobile, cn_id_card, email, bearer_token) or regular expressions,
+// hashKey is the HMAC-SHA256 key required by the hash mode
+// 创建Redactor，keys为整体脱敏的属性名(不区分大小写)，patterns为内置规则名或正则表达式，mode为mask或hash，hash模式必须配置hashKey
+func NewRedactor(keys []string, patterns []string, mode string, hashKey []byte) (*Redactor, error) {
+	switch mode {
+	case RedactionModeMask:
+		hashKey = nil
+	case RedactionModeHash:
+		if len(hashKey) == 0 {
+			return nil, errors.New("redaction hash mode requires a hash key")
+		}
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q, must be mask or hash", mode)
+	}
+	r := &Redactor{keys: map[string]struct{}{}, hashKey: hashKey}
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+	}
+	for _, pattern := range patterns {
+		digitBoundary := digitBoundaryPatterns[pattern]
+		if builtin, ok := builtinRedactionPatterns[pattern]; ok {
+			pattern = builtin
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, redactionPattern{re: re, digitBoundary: digitBoundary})
+	}
+	return r, nil
+}
+
+func (r *Redactor) replace(s string) string {
+	if r.hashKey == nil {
+		return redactionMask
+	}
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(s))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RedactString 脱敏字符串中匹配正则表达式的内容
+func (r *Redactor) RedactString(s string) string {
+	for i := range r.patterns {
+		s = r.patterns[i].redact(s, r.replace)
+	}
+	return s
+}
+
+// RedactKeyValue 脱敏单个属性，属性名在deny-list中时整体脱敏，否则脱敏字符串中匹配的内容
+func (r *Redactor) RedactKeyValue(kv attribute.KeyValue) attribute.KeyValue {
+	_, deny := r.keys[strings.ToLower(string(kv.Key))]
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		if deny {
+			return kv.Key.String(r.replace(kv.Value.AsString()))
+		}
+		return kv.Key.String(r.RedactString(kv.Value.AsString()))
+	case attribute.STRINGSLICE:
+		values := kv.Value.AsStringSlice()
+		for i, v := range values {
+			if deny {
+				values[i] = r.replace(v)
+			} else {
+				values[i] = r.RedactString(v)
+			}
+		}
+		return kv.Key.StringSlice(values)
+	}
+	if deny {
+		return kv.Key.String(r.replace(kv.Value.Emit()))
+	}
+	return kv
+}
+
+// RedactAttributes 脱敏属性列表，返回新的列表
+func (r *Redactor) RedactAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if len(attrs) == 0 {
+		return attrs
+	}
+	result := make([]attribute.KeyValue, len(attrs))
+	for i, kv := range attrs {
+		result[i] = r.RedactKeyValue(kv)
+	}
+	return result
+}
+
+// RedactLogKeyValue 脱敏单个日志属性，Slice和Map中的值递归脱敏，属性名在deny-list中时其中的值全部脱敏
+func (r *Redactor) RedactLogKeyValue(kv log.KeyValue) log.KeyValue {
+	_, deny := r.keys[strings.ToLower(kv.Key)]
+	return log.KeyValue{Key: kv.Key, Value: r.redactLogValue(kv.Value, deny)}
+}
+
+// RedactLogKeyValues 脱敏日志属性列表，返回新的列表
+func (r *Redactor) RedactLogKeyValues(kvs []log.KeyValue) []log.KeyValue {
+	if len(kvs) == 0 {
+		return kvs
+	}
+	result := make([]log.KeyValue, len(kvs))
+	for i, kv := range kvs {
+		result[i] = r.RedactLogKeyValue(kv)
+	}
+	return result
+}
+
+func (r *Redactor) redactLogValue(v log.Value, deny bool) log.Value {
+	switch v.Kind() {
+	case log.KindEmpty:
+		return v
+	case log.KindString:
+		if deny {
+			return log.StringValue(r.replace(v.AsString()))
+		}
+		return log.StringValue(r.RedactString(v.AsString()))
+	case log.KindSlice:
+		values := v.AsSlice()
+		result := make([]log.Value, len(values))
+		for i, item := range values {
+			result[i] = r.redactLogValue(item, deny)
+		}
+		return log.SliceValue(result...)
+	case log.KindMap:
+		kvs := v.AsMap()
+		result := make([]log.KeyValue, len(kvs))
+		for i, kv := range kvs {
+			if deny {
+				result[i] = log.KeyValue{Key: kv.Key, Value: r.redactLogValue(kv.Value, true)}
+			} else {
+				result[i] = r.RedactLogKeyValue(kv)
+			}
+		}
+		return log.MapValue(result...)
+	}
+	if deny {
+		return log.StringValue(r.replace(v.String()))
+	}
+	return v
+}
+
+// Redactor returns the redactor configured by WithRedaction, it is nil when redaction is not enabled,
+// the error is already reported by NewConfig and Start for an invalid configuration
+// 根据配置创建Redactor，未开启脱敏时返回nil
+func (c *Config) Redactor() (*Redactor, error) {
+	if !c.RedactionEnabled {
+		return nil, nil
+	}
+	patterns := splitList(c.RedactionBuiltinPatterns)
+	if c.RedactionPattern != "" {
+		patterns = append(patterns, c.RedactionPattern)
+	}
+	patterns = append(patterns, c.redactionPatterns...)
+	return NewRedactor(splitList(c.RedactionKeys), patterns, c.RedactionMode, []byte(c.RedactionHashKey))
+}
+
+// redactedSpan 替换属性、事件、链接和状态描述为脱敏后的内容
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+	attributes []attribute.KeyValue
+	events     []sdktrace.Event
+	links      []sdktrace.Link
+	status     sdktrace.Status
+}
+
+func (s *redactedSpan) Attributes() []attribute.KeyValue { return s.attributes }
+
+func (s *redactedSpan) Events() []sdktrace.Event { return s.events }
+
+func (s *redactedSpan) Links() []sdktrace.Link { return s.links }
+
+func (s *redactedSpan) Status() sdktrace.Status { return s.status }
+
+// redactionProcessor 在Span进入BatchSpanProcessor之前脱敏
+type redactionProcessor struct {
+	sdktrace.SpanProcessor
+	redactor *Redactor
+}
+
+func (p *redactionProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	events := make([]sdktrace.Event, 0, len(s.Events()))
+	for _, e := range s.Events() {
+		e.Attributes = p.redactor.RedactAttributes(e.Attributes)
+		events = append(events, e)
+	}
+	links := make([]sdktrace.Link, 0, len(s.Links()))
+	for _, l := range s.Links() {
+		l.Attributes = p.redactor.RedactAttributes(l.Attributes)
+		links = append(links, l)
+	}
+	status := s.Status()
+	status.Description = p.redactor.RedactString(status.Description)
+	p.SpanProcessor.OnEnd(&redactedSpan{
+		ReadOnlySpan: s,
+		attributes:   p.redactor.RedactAttributes(s.Attributes()),
+		events:       events,
+		links:        links,
+		status:       status,
+	})
+}
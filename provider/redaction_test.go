@@ -0,0 +1,196 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func hmacHex(key, value string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestRedactorMask(t *testing.T) {
+	r, err := NewRedactor([]string{"Password"}, []string{RedactionPatternCNMobile, RedactionPatternEmail, `order-\d+`}, RedactionModeMask, []byte("ignored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in, want attribute.KeyValue
+	}{
+		{attribute.String("password", "secret"), attribute.String("password", redactionMask)},
+		{attribute.Int("PASSWORD", 1234), attribute.String("PASSWORD", redactionMask)},
+		{attribute.String("msg", "call 13812345678 or a.b@example.com"), attribute.String("msg", "call **** or ****")},
+		{attribute.StringSlice("ids", []string{"order-1", "x"}), attribute.StringSlice("ids", []string{"****", "x"})},
+		{attribute.Int("count", 13812345678), attribute.Int("count", 13812345678)},
+	}
+	for _, tt := range tests {
+		if got := r.RedactKeyValue(tt.in); got != tt.want {
+			t.Errorf("RedactKeyValue(%v) = %v, want %v", tt.in, got.Value.Emit(), tt.want.Value.Emit())
+		}
+	}
+}
+
+func TestRedactorBuiltinPatterns(t *testing.T) {
+	r, err := NewRedactor(nil, []string{RedactionPatternCNMobile, RedactionPatternCNIDCard}, RedactionModeMask, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"+8613812345678", "****"},
+		{"tel:+86 13812345678.", "tel:****."},
+		{"008613812345678", "****"},
+		{"电话13812345678，谢谢", "电话****，谢谢"},
+		{"uid13812345678", "uid****"},
+		{"13812345678,13912345678", "****,****"},
+		{"order 213812345678", "order 213812345678"},
+		{"138123456789", "138123456789"},
+		{"身份证110101199003071234号", "身份证****号"},
+		{"id 11010119900307123X", "id ****"},
+		{"1101011990030712345", "1101011990030712345"},
+	}
+	for _, tt := range tests {
+		if got := r.RedactString(tt.in); got != tt.want {
+			t.Errorf("RedactString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactorLogKeyValue(t *testing.T) {
+	r, err := NewRedactor([]string{"password"}, []string{RedactionPatternCNMobile}, RedactionModeMask, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in, want log.KeyValue
+	}{
+		{log.String("msg", "tel 13812345678"), log.String("msg", "tel ****")},
+		{log.Int("Password", 1234), log.String("Password", redactionMask)},
+		{log.Int("count", 13812345678), log.Int("count", 13812345678)},
+		{log.Slice("phones", log.StringValue("13812345678"), log.IntValue(1)),
+			log.Slice("phones", log.StringValue(redactionMask), log.IntValue(1))},
+		{log.Map("user", log.String("password", "secret"), log.String("phone", "13812345678"), log.Int("age", 20)),
+			log.Map("user", log.String("password", redactionMask), log.String("phone", redactionMask), log.Int("age", 20))},
+		{log.Map("password", log.String("old", "a"), log.Int("version", 2)),
+			log.Map("password", log.String("old", redactionMask), log.String("version", redactionMask))},
+		{log.Empty("password"), log.Empty("password")},
+	}
+	for _, tt := range tests {
+		if got := r.RedactLogKeyValue(tt.in); !got.Equal(tt.want) {
+			t.Errorf("RedactLogKeyValue(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	if got := r.RedactLogKeyValues(nil); got != nil {
+		t.Errorf("RedactLogKeyValues(nil) = %v", got)
+	}
+}
+
+func TestRedactorHash(t *testing.T) {
+	r, err := NewRedactor([]string{"user.id"}, []string{RedactionPatternCNMobile}, RedactionModeHash, []byte("key-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.RedactString("tel 13812345678"), "tel "+hmacHex("key-1", "13812345678"); got != want {
+		t.Fatalf("RedactString = %q, want %q", got, want)
+	}
+	a := r.RedactKeyValue(attribute.String("user.id", "42"))
+	b := r.RedactKeyValue(attribute.String("user.id", "42"))
+	if a != b || a.Value.AsString() != hmacHex("key-1", "42") {
+		t.Fatalf("hash is not stable: %q, %q", a.Value.AsString(), b.Value.AsString())
+	}
+
+	other, err := NewRedactor([]string{"user.id"}, nil, RedactionModeHash, []byte("key-2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.RedactKeyValue(attribute.String("user.id", "42")) == a {
+		t.Fatal("different keys produced the same hash")
+	}
+}
+
+func TestRedactorConfig(t *testing.T) {
+	if _, err := NewRedactor(nil, nil, RedactionModeHash, nil); err == nil {
+		t.Error("hash mode without a key should fail")
+	}
+	if _, err := NewRedactor(nil, nil, "rot13", nil); err == nil {
+		t.Error("unknown mode should fail")
+	}
+	if _, err := NewRedactor(nil, []string{"("}, RedactionModeMask, nil); err == nil {
+		t.Error("invalid pattern should fail")
+	}
+
+	if _, err := NewConfig(WithServiceName("test"), WithRedaction(RedactionModeHash, nil)); err == nil {
+		t.Error("config with hash mode and no key should be invalid")
+	}
+	c, err := NewConfig(WithServiceName("test"), WithRedaction(RedactionModeHash, nil), WithRedactionHashKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Dump()["SLS_OTEL_REDACTION_HASH_KEY"]; got != redacted {
+		t.Errorf("hash key is dumped as %q", got)
+	}
+}
+
+func TestRedactionProcessor(t *testing.T) {
+	exporter := &recordingSpanExporter{}
+	startTestTracer(t, exporter, WithRedaction(RedactionModeMask, []string{"user.email"}, RedactionPatternCNMobile))
+
+	_, span := otel.Tracer("test").Start(context.Background(), "span",
+		trace.WithAttributes(attribute.String("user.email", "a@example.com"), attribute.String("msg", "tel 13812345678")),
+		trace.WithLinks(trace.Link{Attributes: []attribute.KeyValue{attribute.String("user.email", "b@example.com")}}))
+	span.AddEvent("event", trace.WithAttributes(attribute.String("msg", "13912345678")))
+	span.SetStatus(codes.Error, "failed for 13712345678")
+	span.End()
+	forceFlush(t)
+
+	spans := exporter.spans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans", len(spans))
+	}
+	s := spans[0]
+	want := []attribute.KeyValue{attribute.String("user.email", redactionMask), attribute.String("msg", "tel "+redactionMask)}
+	for _, kv := range want {
+		found := false
+		for _, got := range s.Attributes() {
+			found = found || got == kv
+		}
+		if !found {
+			t.Errorf("attribute %v not found in %v", kv, s.Attributes())
+		}
+	}
+	if got := s.Events()[0].Attributes[0].Value.AsString(); got != redactionMask {
+		t.Errorf("event attribute = %q", got)
+	}
+	if got := s.Links()[0].Attributes[0].Value.AsString(); got != redactionMask {
+		t.Errorf("link attribute = %q", got)
+	}
+	if got := s.Status().Description; got != "failed for "+redactionMask {
+		t.Errorf("status description = %q", got)
+	}
+}
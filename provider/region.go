@@ -0,0 +1,226 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// SLSNetwork is the network type used to reach the SLS endpoint
+// SLS的接入网络类型
+type SLSNetwork string
+
+const (
+	// NetworkPublic 公网接入
+	NetworkPublic SLSNetwork = "public"
+	// NetworkIntranet 经典网络内网接入
+	NetworkIntranet SLSNetwork = "intranet"
+	// NetworkVPC VPC网络接入，与经典网络共用内网域名
+	NetworkVPC SLSNetwork = "vpc"
+	// NetworkAcceleration 全球加速接入
+	NetworkAcceleration SLSNetwork = "acceleration"
+)
+
+const (
+	slsDomainSuffix       = ".log.aliyuncs.com"
+	slsAccelerationDomain = "log-global.aliyuncs.com"
+	slsOtlpPort           = "10010"
+)
+
+// slsRegions 已知的SLS地域列表
+var slsRegions = map[string]struct{}{
+	"cn-hangzhou":           {},
+	"cn-hangzhou-finance":   {},
+	"cn-shanghai":           {},
+	"cn-shanghai-finance-1": {},
+	"cn-qingdao":            {},
+	"cn-beijing":            {},
+	"cn-beijing-finance-1":  {},
+	"cn-zhangjiakou":        {},
+	"cn-huhehaote":          {},
+	"cn-wulanchabu":         {},
+	"cn-shenzhen":           {},
+	"cn-shenzhen-finance-1": {},
+	"cn-heyuan":             {},
+	"cn-guangzhou":          {},
+	"cn-chengdu":            {},
+	"cn-nanjing":            {},
+	"cn-fuzhou":             {},
+	"cn-wuhan-lr":           {},
+	"cn-hongkong":           {},
+	"ap-northeast-1":        {},
+	"ap-northeast-2":        {},
+	"ap-southeast-1":        {},
+	"ap-southeast-2":        {},
+	"ap-southeast-3":        {},
+	"ap-southeast-5":        {},
+	"ap-southeast-6":        {},
+	"ap-southeast-7":        {},
+	"ap-south-1":            {},
+	"us-east-1":             {},
+	"us-west-1":             {},
+	"eu-central-1":          {},
+	"eu-west-1":             {},
+	"me-east-1":             {},
+	"me-central-1":          {},
+}
+
+// slsEndpoint is the structured form of an OTLP endpoint
+type slsEndpoint struct {
+	Host    string
+	Port    string
+	Project string
+	Region  string
+	Network SLSNetwork
+}
+
+// String returns the endpoint in host:port form
+func (e slsEndpoint) String() string {
+	return net.JoinHostPort(e.Host, e.Port)
+}
+
+// IsSLS reports whether the endpoint points to SLS directly
+func (e slsEndpoint) IsSLS() bool {
+	return e.Network != ""
+}
+
+func isValidRegion(region string) bool {
+	_, ok := slsRegions[region]
+	return ok
+}
+
+func isValidNetwork(network SLSNetwork) bool {
+	switch network {
+	case NetworkPublic, NetworkIntranet, NetworkVPC, NetworkAcceleration:
+		return true
+	}
+	return false
+}
+
+// buildSLSEndpoint 根据project、region和网络类型生成SLS的OTLP接入地址
+func buildSLSEndpoint(project, region string, network SLSNetwork) (slsEndpoint, error) {
+	if project == "" {
+		return slsEndpoint{}, fmt.Errorf("empty project when resolving sls endpoint for region %q", region)
+	}
+	if !isValidRegion(region) {
+		return slsEndpoint{}, fmt.Errorf("unknown sls region %q", region)
+	}
+	e := slsEndpoint{Port: slsOtlpPort, Project: project, Region: region, Network: network}
+	switch network {
+	case NetworkPublic, "":
+		e.Network = NetworkPublic
+		e.Host = project + "." + region + slsDomainSuffix
+	case NetworkIntranet, NetworkVPC:
+		e.Host = project + "." + region + "-intranet" + slsDomainSuffix
+	case NetworkAcceleration:
+		e.Host = project + "." + slsAccelerationDomain
+	default:
+		return slsEndpoint{}, fmt.Errorf("unknown sls network %q", network)
+	}
+	return e, nil
+}
+
+// parseEndpoint 解析 host:port 形式的地址，识别是否为SLS的接入地址
+func parseEndpoint(endpoint string) (slsEndpoint, error) {
+	host, port := endpoint, ""
+	if strings.Contains(endpoint, ":") {
+		var err error
+		if host, port, err = net.SplitHostPort(endpoint); err != nil {
+			return slsEndpoint{}, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+		}
+	}
+	e := slsEndpoint{Host: strings.ToLower(host), Port: port}
+
+	if strings.HasSuffix(e.Host, "."+slsAccelerationDomain) {
+		e.Project = strings.TrimSuffix(e.Host, "."+slsAccelerationDomain)
+		e.Network = NetworkAcceleration
+		return e, nil
+	}
+	if !strings.HasSuffix(e.Host, slsDomainSuffix) {
+		return e, nil
+	}
+	// [<project>.]<region>[-intranet].log.aliyuncs.com，所有SLS域名都需要校验加密和鉴权配置
+	labels := strings.Split(strings.TrimSuffix(e.Host, slsDomainSuffix), ".")
+	e.Region, e.Network = labels[len(labels)-1], NetworkPublic
+	if len(labels) > 1 {
+		e.Project = labels[0]
+	}
+	if strings.HasSuffix(e.Region, "-intranet") {
+		e.Region, e.Network = strings.TrimSuffix(e.Region, "-intranet"), NetworkIntranet
+	}
+	return e, nil
+}
+
+// resolveEndpoints 配置了Region时，为没有通过环境变量或Option显式配置的Trace/Metric/Log生成SLS接入地址，
+// 显式配置的地址(包括stdout和表示禁用的空字符串)不会被覆盖
+func resolveEndpoints(c *Config) error {
+	if c.Region == "" {
+		return nil
+	}
+	e, err := buildSLSEndpoint(c.Project, c.Region, c.Network)
+	if err != nil {
+		return err
+	}
+	c.Network = e.Network
+	for _, endpoint := range []struct {
+		env      string
+		explicit bool
+		value    *string
+	}{
+		{"SLS_OTEL_TRACE_ENDPOINT", c.explicitTraceEndpoint, &c.TraceExporterEndpoint},
+		{"SLS_OTEL_METRIC_ENDPOINT", c.explicitMetricEndpoint, &c.MetricExporterEndpoint},
+		{"SLS_OTEL_LOG_ENDPOINT", c.explicitLogEndpoint, &c.LogExporterEndpoint},
+	} {
+		if _, ok := os.LookupEnv(endpoint.env); ok || endpoint.explicit {
+			continue
+		}
+		*endpoint.value = e.String()
+	}
+	return nil
+}
+
+// validateEndpoint 检查直连SLS时的地址、加密和鉴权配置
+func (c *Config) validateEndpoint(endpoint string, insecure bool) error {
+	if endpoint == "" || endpoint == "stdout" || endpoint == prometheusEndpoint {
+		return nil
+	}
+	e, err := parseEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+	if !e.IsSLS() {
+		return nil
+	}
+	if insecure {
+		return errors.New("insecure grpc is not allowed when send data to sls directly")
+	}
+	if c.Project == "" || c.InstanceID == "" || c.AccessKeyID == "" || c.AccessKeySecret == "" {
+		return errors.New("empty project, instanceID, accessKeyID or accessKeySecret when send data to sls directly")
+	}
+	if strings.ContainsAny(c.Project, "${}") ||
+		strings.ContainsAny(c.InstanceID, "${}") ||
+		strings.ContainsAny(c.AccessKeyID, "${}") ||
+		strings.ContainsAny(c.AccessKeySecret, "${}") {
+		return errors.New("invalid project, instanceID, accessKeyID or accessKeySecret when send data to sls directly, you should replace these parameters with actual values")
+	}
+	if e.Project != "" && e.Project != c.Project {
+		return fmt.Errorf("project %q in endpoint %q does not match configured project %q", e.Project, endpoint, c.Project)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import "testing"
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     slsEndpoint
+	}{
+		{"proj.cn-hangzhou.log.aliyuncs.com:10010", slsEndpoint{Host: "proj.cn-hangzhou.log.aliyuncs.com", Port: "10010", Project: "proj", Region: "cn-hangzhou", Network: NetworkPublic}},
+		{"Proj.CN-Hangzhou-Intranet.log.aliyuncs.com:10010", slsEndpoint{Host: "proj.cn-hangzhou-intranet.log.aliyuncs.com", Port: "10010", Project: "proj", Region: "cn-hangzhou", Network: NetworkIntranet}},
+		{"proj.log-global.aliyuncs.com:10010", slsEndpoint{Host: "proj.log-global.aliyuncs.com", Port: "10010", Project: "proj", Network: NetworkAcceleration}},
+		{"cn-hangzhou.log.aliyuncs.com", slsEndpoint{Host: "cn-hangzhou.log.aliyuncs.com", Region: "cn-hangzhou", Network: NetworkPublic}},
+		{"a.b.cn-hangzhou.log.aliyuncs.com:443", slsEndpoint{Host: "a.b.cn-hangzhou.log.aliyuncs.com", Port: "443", Project: "a", Region: "cn-hangzhou", Network: NetworkPublic}},
+		{"collector.example.com:4317", slsEndpoint{Host: "collector.example.com", Port: "4317"}},
+		{"127.0.0.1:4317", slsEndpoint{Host: "127.0.0.1", Port: "4317"}},
+	}
+	for _, tt := range tests {
+		got, err := parseEndpoint(tt.endpoint)
+		if err != nil {
+			t.Fatalf("parseEndpoint(%q): %v", tt.endpoint, err)
+		}
+		if got != tt.want {
+			t.Errorf("parseEndpoint(%q) = %+v, want %+v", tt.endpoint, got, tt.want)
+		}
+		if got.IsSLS() != (tt.want.Network != "") {
+			t.Errorf("parseEndpoint(%q).IsSLS() = %v", tt.endpoint, got.IsSLS())
+		}
+	}
+
+	if _, err := parseEndpoint("[::1"); err == nil {
+		t.Error("parseEndpoint of a malformed endpoint should fail")
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	c := &Config{Project: "proj", InstanceID: "instance", AccessKeyID: "id", AccessKeySecret: "secret"}
+	tests := []struct {
+		endpoint string
+		insecure bool
+		wantErr  bool
+	}{
+		{"proj.cn-hangzhou.log.aliyuncs.com:10010", false, false},
+		{"proj.cn-hangzhou.log.aliyuncs.com:10010", true, true},
+		{"other.cn-hangzhou.log.aliyuncs.com:10010", false, true},
+		{"cn-hangzhou.log.aliyuncs.com:10010", true, true},
+		{"a.b.cn-hangzhou.log.aliyuncs.com:10010", true, true},
+		{"collector.example.com:4317", true, false},
+		{"stdout", true, false},
+	}
+	for _, tt := range tests {
+		err := c.validateEndpoint(tt.endpoint, tt.insecure)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateEndpoint(%q, %v) = %v, want error %v", tt.endpoint, tt.insecure, err, tt.wantErr)
+		}
+	}
+
+	if err := (&Config{}).validateEndpoint("cn-hangzhou.log.aliyuncs.com:10010", false); err == nil {
+		t.Error("validateEndpoint without credentials should fail for sls hosts")
+	}
+}
+
+func TestResolveEndpoints(t *testing.T) {
+	unsetEnv(t, "SLS_OTEL_TRACE_ENDPOINT")
+	unsetEnv(t, "SLS_OTEL_METRIC_ENDPOINT")
+	unsetEnv(t, "SLS_OTEL_LOG_ENDPOINT")
+	const derived = "proj.cn-beijing-intranet.log.aliyuncs.com:10010"
+
+	// 没有显式配置时，Trace、Metric和Log都使用SLS地址
+	c, err := NewConfig(WithServiceName("test"), WithSLSConfig("proj", "instance", "ak", "sk"), WithSLSRegion("cn-beijing", NetworkVPC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TraceExporterEndpoint != derived || c.MetricExporterEndpoint != derived || c.LogExporterEndpoint != derived {
+		t.Errorf("endpoints = %q, %q, %q, want %q", c.TraceExporterEndpoint, c.MetricExporterEndpoint, c.LogExporterEndpoint, derived)
+	}
+
+	// 通过Option显式配置的地址保持不变，包括stdout和表示禁用的空字符串
+	c, err = NewConfig(WithServiceName("test"), WithSLSConfig("proj", "instance", "ak", "sk"), WithSLSRegion("cn-beijing", NetworkVPC),
+		WithTraceExporterEndpoint("stdout"), WithMetricExporterEndpoint("collector:4317"), WithLogExporterEndpoint(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TraceExporterEndpoint != "stdout" || c.MetricExporterEndpoint != "collector:4317" || c.LogExporterEndpoint != "" {
+		t.Errorf("explicit endpoints were overwritten: %q, %q, %q", c.TraceExporterEndpoint, c.MetricExporterEndpoint, c.LogExporterEndpoint)
+	}
+
+	// 通过环境变量显式配置的地址同样保持不变
+	t.Setenv("SLS_OTEL_TRACE_ENDPOINT", "stdout")
+	t.Setenv("SLS_OTEL_LOG_ENDPOINT", "")
+	c, err = NewConfig(WithServiceName("test"), WithSLSConfig("proj", "instance", "ak", "sk"), WithSLSRegion("cn-beijing", NetworkVPC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TraceExporterEndpoint != "stdout" || c.MetricExporterEndpoint != derived || c.LogExporterEndpoint != "" {
+		t.Errorf("endpoints = %q, %q, %q", c.TraceExporterEndpoint, c.MetricExporterEndpoint, c.LogExporterEndpoint)
+	}
+
+	c, err = NewConfig(WithServiceName("test"), WithSLSConfig("proj", "instance", "ak", "sk"), WithSLSRegion("cn-hangzhou", ""),
+		WithPrometheusExporter("127.0.0.1:0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MetricExporterEndpoint != prometheusEndpoint || c.Network != NetworkPublic {
+		t.Errorf("got metric endpoint %q network %q", c.MetricExporterEndpoint, c.Network)
+	}
+
+	if err := resolveEndpoints(&Config{Region: "cn-hangzhou"}); err == nil {
+		t.Error("resolveEndpoints without project should fail")
+	}
+	if err := resolveEndpoints(&Config{Project: "proj", Region: "mars-1"}); err == nil {
+		t.Error("resolveEndpoints with an unknown region should fail")
+	}
+}
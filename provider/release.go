@@ -0,0 +1,137 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+// release channels
+const (
+	ReleaseChannelStable = "stable"
+	ReleaseChannelCanary = "canary"
+)
+
+// ReleaseChannelKey 发布通道的Resource属性名，同时也是请求链路中传递发布通道的Baggage键
+const ReleaseChannelKey = attribute.Key("release.channel")
+
+// readPodLabels 读取Kubernetes Downward API挂载的Pod标签文件，每行格式为 key="value"
+func readPodLabels(path string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	labels := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		labels[strings.TrimSpace(key)] = value
+	}
+	return labels
+}
+
+// detectRelease 未配置部署环境或发布通道时，从Pod标签中读取
+func detectRelease(c *Config) {
+	if (c.DeploymentEnvironment != "" && c.ReleaseChannel != "") || c.PodLabelsFile == "" {
+		return
+	}
+	labels := readPodLabels(c.PodLabelsFile)
+	if c.DeploymentEnvironment == "" {
+		c.DeploymentEnvironment = labels[c.DeploymentEnvLabel]
+	}
+	if c.ReleaseChannel == "" {
+		c.ReleaseChannel = labels[c.ReleaseChannelLabel]
+	}
+}
+
+// releaseAttributes 返回部署环境和发布通道对应的Resource属性
+func (c *Config) releaseAttributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if c.DeploymentEnvironment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentKey.String(c.DeploymentEnvironment))
+	}
+	if c.ReleaseChannel != "" {
+		attrs = append(attrs, ReleaseChannelKey.String(c.ReleaseChannel))
+	}
+	return attrs
+}
+
+// ContextWithReleaseChannel returns a copy of ctx whose baggage carries the release channel,
+// the channel is propagated to downstream services by the Baggage propagator
+// 在Baggage中记录请求的发布通道，下游服务可以据此判断是否为灰度请求
+func ContextWithReleaseChannel(ctx context.Context, channel string) context.Context {
+	member, err := baggage.NewMember(string(ReleaseChannelKey), channel)
+	if err != nil {
+		otel.Handle(err)
+		return ctx
+	}
+	b, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		otel.Handle(err)
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, b)
+}
+
+// ReleaseChannelFromContext 返回请求的发布通道，未设置时返回空字符串
+func ReleaseChannelFromContext(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(string(ReleaseChannelKey)).Value()
+}
+
+// IsCanary reports whether the request passed through a service that is not on the stable channel
+// 判断请求是否为灰度请求，发布通道非空且不是stable时为灰度请求
+func IsCanary(ctx context.Context) bool {
+	channel := ReleaseChannelFromContext(ctx)
+	return channel != "" && channel != ReleaseChannelStable
+}
+
+// releaseChannelPropagator 注入Baggage时，本服务不是stable且请求尚未标记发布通道或标记为stable时标记为本服务的发布通道，
+// 经过灰度服务的请求及其下游调用都会被标记为灰度请求，stable服务不会添加发布通道
+type releaseChannelPropagator struct {
+	propagation.Baggage
+	channel string
+}
+
+// NewReleaseChannelPropagator returns a Baggage propagator that marks outgoing requests with channel
+// unless they already carry a non-stable release channel, it replaces propagation.Baggage in the composite propagator
+// 创建标记发布通道的Baggage Propagator，请求已标记为灰度时保持不变，用于替换propagation.Baggage
+func NewReleaseChannelPropagator(channel string) propagation.TextMapPropagator {
+	return releaseChannelPropagator{channel: channel}
+}
+
+func (p releaseChannelPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	if current := ReleaseChannelFromContext(ctx); p.channel != "" && p.channel != ReleaseChannelStable &&
+		p.channel != current && (current == "" || current == ReleaseChannelStable) {
+		ctx = ContextWithReleaseChannel(ctx, p.channel)
+	}
+	p.Baggage.Inject(ctx, carrier)
+}
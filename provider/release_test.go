@@ -0,0 +1,136 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// propagate 模拟一次跨服务调用，返回下游服务从请求头中提取的Context
+func propagate(ctx context.Context, p propagation.TextMapPropagator) context.Context {
+	carrier := propagation.MapCarrier{}
+	p.Inject(ctx, carrier)
+	return p.Extract(context.Background(), carrier)
+}
+
+func TestReleaseChannelPropagator(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		local    string
+		want     string
+	}{
+		{name: "unset marked with local", local: ReleaseChannelCanary, want: ReleaseChannelCanary},
+		{name: "stable upgraded to canary", incoming: ReleaseChannelStable, local: ReleaseChannelCanary, want: ReleaseChannelCanary},
+		{name: "canary kept on stable", incoming: ReleaseChannelCanary, local: ReleaseChannelStable, want: ReleaseChannelCanary},
+		{name: "canary kept on other channel", incoming: ReleaseChannelCanary, local: "beta", want: ReleaseChannelCanary},
+		{name: "stable kept on stable", incoming: ReleaseChannelStable, local: ReleaseChannelStable, want: ReleaseChannelStable},
+		{name: "unset not marked on stable", local: ReleaseChannelStable},
+		{name: "no local channel", incoming: ReleaseChannelStable, want: ReleaseChannelStable},
+		{name: "nothing to propagate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.incoming != "" {
+				ctx = ContextWithReleaseChannel(ctx, tt.incoming)
+			}
+			got := ReleaseChannelFromContext(propagate(ctx, NewReleaseChannelPropagator(tt.local)))
+			if got != tt.want {
+				t.Fatalf("release channel = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseChannelThroughServices(t *testing.T) {
+	stable := NewReleaseChannelPropagator(ReleaseChannelStable)
+	canary := NewReleaseChannelPropagator(ReleaseChannelCanary)
+	// stable -> canary -> stable，经过灰度服务后的调用都标记为灰度请求
+	ctx := propagate(context.Background(), stable)
+	if IsCanary(ctx) {
+		t.Fatal("request from stable service should not be canary")
+	}
+	ctx = propagate(ctx, canary)
+	if !IsCanary(ctx) {
+		t.Fatal("request from canary service should be canary")
+	}
+	if ctx = propagate(ctx, stable); !IsCanary(ctx) {
+		t.Fatal("downstream of canary service should stay canary")
+	}
+}
+
+func TestReleaseChannelOnlyWhenConfigured(t *testing.T) {
+	unsetEnv(t, "SLS_OTEL_RELEASE_CHANNEL")
+	incoming := ContextWithReleaseChannel(context.Background(), ReleaseChannelCanary)
+
+	// 未配置发布通道时不添加Baggage，也不把请求的发布通道记录到Span上
+	exporter := &recordingSpanExporter{}
+	startTestTracer(t, exporter)
+	if carrier := inject(context.Background()); carrier.Get("baggage") != "" {
+		t.Fatalf("baggage = %q without release channel", carrier.Get("baggage"))
+	}
+	_, span := otel.Tracer("test").Start(incoming, "span")
+	span.End()
+	forceFlush(t)
+	if attrs := exporter.spans()[0].Attributes(); len(attrs) != 0 {
+		t.Fatalf("attributes = %v without release channel", attrs)
+	}
+
+	exporter = &recordingSpanExporter{}
+	startTestTracer(t, exporter, WithReleaseChannel(ReleaseChannelCanary))
+	if got := ReleaseChannelFromContext(otel.GetTextMapPropagator().Extract(context.Background(), inject(context.Background()))); got != ReleaseChannelCanary {
+		t.Fatalf("release channel = %q, want canary", got)
+	}
+	_, span = otel.Tracer("test").Start(incoming, "span")
+	span.End()
+	forceFlush(t)
+	if attrs := exporter.spans()[0].Attributes(); len(attrs) != 1 || attrs[0] != attribute.String("baggage."+string(ReleaseChannelKey), ReleaseChannelCanary) {
+		t.Fatalf("attributes = %v", attrs)
+	}
+}
+
+// inject 使用全局Propagator注入ctx
+func inject(ctx context.Context) propagation.MapCarrier {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+func TestDetectRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels")
+	content := "app=\"demo\"\nenv=\"staging\"\nchannel=\"canary\"\ninvalid\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{PodLabelsFile: path, DeploymentEnvLabel: "env", ReleaseChannelLabel: "channel"}
+	detectRelease(c)
+	if c.DeploymentEnvironment != "staging" || c.ReleaseChannel != ReleaseChannelCanary {
+		t.Fatalf("environment = %q, channel = %q", c.DeploymentEnvironment, c.ReleaseChannel)
+	}
+
+	c = &Config{PodLabelsFile: path, DeploymentEnvLabel: "env", ReleaseChannelLabel: "channel", ReleaseChannel: ReleaseChannelStable}
+	detectRelease(c)
+	if c.ReleaseChannel != ReleaseChannelStable {
+		t.Fatalf("configured channel overridden by pod label: %q", c.ReleaseChannel)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// envSampler 按照OTEL_TRACES_SAMPLER和OTEL_TRACES_SAMPLER_ARG创建Sampler，与SDK的默认行为一致，
+// 未设置或设置错误时使用ParentBased(AlwaysSample)
+func envSampler() sdktrace.Sampler {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_SAMPLER")))
+	arg, hasArg := os.LookupEnv("OTEL_TRACES_SAMPLER_ARG")
+	ratio := 1.0
+	if hasArg && (name == "traceidratio" || name == "parentbased_traceidratio") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+		if err != nil || v < 0 || v > 1 {
+			otel.Handle(fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %q, use 1.0", arg))
+		} else {
+			ratio = v
+		}
+	}
+	switch name {
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	case "always_on":
+		return sdktrace.AlwaysSample()
+	case "always_off":
+		return sdktrace.NeverSample()
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio)
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	}
+	otel.Handle(fmt.Errorf("unsupported sampler %q, use parentbased_always_on", name))
+	return sdktrace.ParentBased(sdktrace.AlwaysSample())
+}
+
+// recordOnlySampler 把不采样的Span改为只记录不导出，使SpanProcessor在采样前也能看到所有Span
+type recordOnlySampler struct {
+	sdktrace.Sampler
+}
+
+func (s recordOnlySampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.Sampler.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+func (s recordOnlySampler) Description() string {
+	return "RecordOnly{" + s.Sampler.Description() + "}"
+}
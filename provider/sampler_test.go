@@ -0,0 +1,73 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestEnvSampler(t *testing.T) {
+	tests := []struct {
+		sampler, arg string
+		want         string
+	}{
+		{"", "", "ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+		{"always_on", "", "AlwaysOnSampler"},
+		{"always_off", "", "AlwaysOffSampler"},
+		{"traceidratio", "0.25", "TraceIDRatioBased{0.25}"},
+		// 参数无效时使用1.0，TraceIDRatioBased(1)即AlwaysOnSampler
+		{"TraceIdRatio", "2", "AlwaysOnSampler"},
+		{"parentbased_always_off", "", "ParentBased{root:AlwaysOffSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+		{"parentbased_traceidratio", "0.5", "ParentBased{root:TraceIDRatioBased{0.5},remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+		{"jaeger_remote", "", "ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sampler, func(t *testing.T) {
+			t.Setenv("OTEL_TRACES_SAMPLER", tt.sampler)
+			if tt.arg == "" {
+				unsetEnv(t, "OTEL_TRACES_SAMPLER_ARG")
+			} else {
+				t.Setenv("OTEL_TRACES_SAMPLER_ARG", tt.arg)
+			}
+			if got := envSampler().Description(); got != tt.want {
+				t.Fatalf("sampler = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordOnlySampler(t *testing.T) {
+	params := sdktrace.SamplingParameters{TraceID: trace.TraceID{1}, Name: "span"}
+	tests := []struct {
+		sampler sdktrace.Sampler
+		want    sdktrace.SamplingDecision
+	}{
+		{sdktrace.AlwaysSample(), sdktrace.RecordAndSample},
+		{sdktrace.NeverSample(), sdktrace.RecordOnly},
+		{sdktrace.ParentBased(sdktrace.NeverSample()), sdktrace.RecordOnly},
+	}
+	for _, tt := range tests {
+		s := recordOnlySampler{tt.sampler}
+		if got := s.ShouldSample(params).Decision; got != tt.want {
+			t.Errorf("%s decision = %v, want %v", s.Description(), got, tt.want)
+		}
+	}
+	if got := (recordOnlySampler{sdktrace.NeverSample()}).Description(); got != "RecordOnly{AlwaysOffSampler}" {
+		t.Errorf("description = %q", got)
+	}
+}
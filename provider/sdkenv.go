@@ -0,0 +1,60 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"os"
+	"sync"
+)
+
+// sdkEnv 记录provider写入的环境变量及其值
+var sdkEnv = struct {
+	sync.Mutex
+	owned map[string]string
+}{owned: map[string]string{}}
+
+// setSDKEnv 设置SDK读取的环境变量，value为空时清除provider之前写入的值，环境变量已被用户设置时不做修改并返回false。
+// SDK的部分特性(OTEL_GO_X_*等)只能通过环境变量开启，SDK在创建Instrument时读取，因此需要在创建MeterProvider之前设置，
+// 并且对进程中所有的SDK生效，直到Shutdown时由resetSDKEnv清除。用户设置的值总是优先
+func setSDKEnv(key, value string) bool {
+	sdkEnv.Lock()
+	defer sdkEnv.Unlock()
+	current, set := os.LookupEnv(key)
+	owned, ok := sdkEnv.owned[key]
+	if set && (!ok || current != owned) {
+		delete(sdkEnv.owned, key)
+		return false
+	}
+	if value == "" {
+		os.Unsetenv(key)
+		delete(sdkEnv.owned, key)
+		return true
+	}
+	os.Setenv(key, value)
+	sdkEnv.owned[key] = value
+	return true
+}
+
+// resetSDKEnv 清除provider写入且未被用户修改的环境变量
+func resetSDKEnv(keys ...string) {
+	sdkEnv.Lock()
+	defer sdkEnv.Unlock()
+	for _, key := range keys {
+		if owned, ok := sdkEnv.owned[key]; ok && os.Getenv(key) == owned {
+			os.Unsetenv(key)
+		}
+		delete(sdkEnv.owned, key)
+	}
+}
@@ -0,0 +1,404 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+const (
+	instrumentationName = "github.com/aliyun-sls/opentelemetry-go-provider-sls/provider"
+	selfMetricPrefix    = "sls.otel.exporter."
+)
+
+// signalStats 记录单个数据类型导出链路的运行状态
+type signalStats struct {
+	name string
+
+	// 配置磁盘缓存时的磁盘队列，发送失败的数据保留在磁盘上重试，不计为丢弃，在健康检查中并发读取
+	diskQueue atomic.Pointer[diskQueue]
+	// Trace的BatchSpanProcessor队列，其他数据类型为nil
+	queue *spanQueue
+
+	exported    atomic.Int64
+	failedItems atomic.Int64
+	failures    atomic.Int64
+	lastSuccess atomic.Int64
+	lastFailure atomic.Int64
+	// 连续失败开始的时间，导出成功后清零
+	failingSince atomic.Int64
+
+	mu        sync.Mutex
+	lastError string
+
+	duration atomic.Pointer[otelmetric.Float64Histogram]
+}
+
+// record 记录一次发送的结果，配置磁盘缓存时由磁盘队列的发送协程调用，记录的是实际发送到服务端的结果
+func (s *signalStats) record(ctx context.Context, items int, elapsed time.Duration, err error) {
+	now := time.Now().UnixNano()
+	if err != nil {
+		s.failures.Add(1)
+		if s.diskQueue.Load() == nil {
+			s.failedItems.Add(int64(items))
+		}
+		s.lastFailure.Store(now)
+		s.failingSince.CompareAndSwap(0, now)
+		s.mu.Lock()
+		s.lastError = err.Error()
+		s.mu.Unlock()
+	} else {
+		s.exported.Add(int64(items))
+		s.lastSuccess.Store(now)
+		s.failingSince.Store(0)
+	}
+	if h := s.duration.Load(); h != nil {
+		(*h).Record(ctx, elapsed.Seconds(), otelmetric.WithAttributes(
+			attribute.String("signal", s.name),
+			attribute.Bool("success", err == nil)))
+	}
+}
+
+func (s *signalStats) diskQueueSize() int64 {
+	if q := s.diskQueue.Load(); q != nil {
+		return q.Len()
+	}
+	return 0
+}
+
+func (s *signalStats) diskDropped() int64 {
+	if q := s.diskQueue.Load(); q != nil {
+		return q.Dropped()
+	}
+	return 0
+}
+
+func (s *signalStats) queueDropped() int64 {
+	if s.queue == nil {
+		return 0
+	}
+	return s.queue.dropped.Load()
+}
+
+func (s *signalStats) dropped() int64 {
+	return s.failedItems.Load() + s.diskDropped() + s.queueDropped()
+}
+
+func (s *signalStats) error() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastError
+}
+
+func (s *signalStats) summary() string {
+	lastSuccess := "never"
+	if ts := s.lastSuccess.Load(); ts > 0 {
+		lastSuccess = time.Unix(0, ts).Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s exported=%d failed=%d dropped=%d disk_queue=%d last_success=%s last_error=%q",
+		s.name, s.exported.Load(), s.failures.Load(), s.dropped(),
+		s.diskQueueSize(), lastSuccess, s.error())
+}
+
+// pipelineStats 记录Trace、Metric和Log导出链路的运行状态
+type pipelineStats struct {
+	traces      *signalStats
+	metrics     *signalStats
+	logs        *signalStats
+	cardinality *cardinalityTracker
+}
+
+func newPipelineStats(c *Config) *pipelineStats {
+	return &pipelineStats{
+		traces:      &signalStats{name: "traces", queue: newSpanQueue(c.bspMaxQueueSize(), c.BSPBlockOnQueueFull)},
+		metrics:     &signalStats{name: "metrics"},
+		logs:        &signalStats{name: "logs"},
+		cardinality: newCardinalityTracker(c.MetricCardinalityLimit),
+	}
+}
+
+func (p *pipelineStats) all() []*signalStats {
+	return []*signalStats{p.traces, p.metrics, p.logs}
+}
+
+func (p *pipelineStats) signal(signal otelSignal) *signalStats {
+	if signal == signalTrace {
+		return p.traces
+	}
+	return p.metrics
+}
+
+// register 在MeterProvider上注册自监控指标
+func (p *pipelineStats) register(meterProvider otelmetric.MeterProvider) error {
+	meter := meterProvider.Meter(instrumentationName)
+	exported, err := meter.Int64ObservableCounter(selfMetricPrefix+"exported",
+		otelmetric.WithDescription("Number of spans, metric data points or log records exported successfully"), otelmetric.WithUnit("{item}"))
+	if err != nil {
+		return err
+	}
+	dropped, err := meter.Int64ObservableCounter(selfMetricPrefix+"dropped",
+		otelmetric.WithDescription("Number of spans, metric data points or log records dropped"), otelmetric.WithUnit("{item}"))
+	if err != nil {
+		return err
+	}
+	failed, err := meter.Int64ObservableCounter(selfMetricPrefix+"failed",
+		otelmetric.WithDescription("Number of failed export requests"), otelmetric.WithUnit("{request}"))
+	if err != nil {
+		return err
+	}
+	lastSuccess, err := meter.Float64ObservableGauge(selfMetricPrefix+"last_success",
+		otelmetric.WithDescription("Unix timestamp of the last successful export"), otelmetric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	capped, err := meter.Int64ObservableGauge(selfMetricPrefix+"cardinality_capped",
+		otelmetric.WithDescription("Number of metrics exceeding the cardinality limit in the last export"), otelmetric.WithUnit("{metric}"))
+	if err != nil {
+		return err
+	}
+	duration, err := meter.Float64Histogram(selfMetricPrefix+"duration",
+		otelmetric.WithDescription("Duration of export requests"), otelmetric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	for _, s := range p.all() {
+		s.duration.Store(&duration)
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o otelmetric.Observer) error {
+		for _, s := range p.all() {
+			signal := attribute.String("signal", s.name)
+			o.ObserveInt64(exported, s.exported.Load(), otelmetric.WithAttributes(signal))
+			o.ObserveInt64(dropped, s.failedItems.Load(), otelmetric.WithAttributes(signal, attribute.String("reason", "export_failed")))
+			o.ObserveInt64(dropped, s.diskDropped(), otelmetric.WithAttributes(signal, attribute.String("reason", "disk_buffer")))
+			if s.queue != nil {
+				o.ObserveInt64(dropped, s.queueDropped(), otelmetric.WithAttributes(signal, attribute.String("reason", "queue_full")))
+			}
+			o.ObserveInt64(failed, s.failures.Load(), otelmetric.WithAttributes(signal))
+			if ts := s.lastSuccess.Load(); ts > 0 {
+				o.ObserveFloat64(lastSuccess, float64(ts)/float64(time.Second), otelmetric.WithAttributes(signal))
+			}
+		}
+		o.ObserveInt64(capped, p.cardinality.capped.Load())
+		return nil
+	}, exported, dropped, failed, lastSuccess, capped)
+	if err != nil {
+		return err
+	}
+	return p.registerDiskQueues(meter)
+}
+
+// registerDiskQueues 配置磁盘缓存时注册磁盘队列的长度指标
+func (p *pipelineStats) registerDiskQueues(meter otelmetric.Meter) error {
+	var queued []*signalStats
+	for _, s := range p.all() {
+		if s.diskQueue.Load() != nil {
+			queued = append(queued, s)
+		}
+	}
+	if len(queued) == 0 {
+		return nil
+	}
+	queueSize, err := meter.Int64ObservableGauge(selfMetricPrefix+"queue.size",
+		otelmetric.WithDescription("Number of batches waiting in the disk buffer"), otelmetric.WithUnit("{batch}"))
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(_ context.Context, o otelmetric.Observer) error {
+		for _, s := range queued {
+			o.ObserveInt64(queueSize, s.diskQueueSize(), otelmetric.WithAttributes(attribute.String("signal", s.name), attribute.String("queue", "disk")))
+		}
+		return nil
+	}, queueSize)
+	return err
+}
+
+// spanQueue 统计BatchSpanProcessor队列中的Span，非阻塞模式下由spanQueueProcessor在队列满时丢弃Span，
+// 使BatchSpanProcessor自身不会因队列满丢弃Span，丢弃的数量可以被统计
+type spanQueue struct {
+	maxSize  int64
+	blocking bool
+	size     atomic.Int64
+	dropped  atomic.Int64
+}
+
+func newSpanQueue(maxSize int, blocking bool) *spanQueue {
+	return &spanQueue{maxSize: int64(maxSize), blocking: blocking}
+}
+
+// enqueue 记录一个进入队列的Span，队列已满时返回false
+func (q *spanQueue) enqueue() bool {
+	for {
+		n := q.size.Load()
+		if !q.blocking && n >= q.maxSize {
+			q.dropped.Add(1)
+			return false
+		}
+		if q.size.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// spanQueueProcessor 在Span进入BatchSpanProcessor之前检查队列容量
+type spanQueueProcessor struct {
+	sdktrace.SpanProcessor
+	queue *spanQueue
+}
+
+func (p *spanQueueProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	// BatchSpanProcessor只导出采样的Span
+	if s.SpanContext().IsSampled() && !p.queue.enqueue() {
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// spanQueueExporter BatchSpanProcessor从队列中取出的Span在导出时从队列长度中扣除
+type spanQueueExporter struct {
+	sdktrace.SpanExporter
+	queue *spanQueue
+}
+
+func (e *spanQueueExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.queue.size.Add(-int64(len(spans)))
+	return e.SpanExporter.ExportSpans(ctx, spans)
+}
+
+// startSummary 定期通过ErrorHandler输出导出链路的统计信息
+func (p *pipelineStats) startSummary(interval time.Duration) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				otel.Handle(fmt.Errorf("sls otel exporter summary: %s; %s; %s", p.traces.summary(), p.metrics.summary(), p.logs.summary()))
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// instrumentedSpanExporter 统计Span导出结果
+type instrumentedSpanExporter struct {
+	sdktrace.SpanExporter
+	stats *signalStats
+}
+
+func (e *instrumentedSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	start := time.Now()
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.stats.record(ctx, len(spans), time.Since(start), err)
+	return err
+}
+
+// instrumentedTraceClient 统计实际发送到服务端的Span，配置磁盘缓存时包装在磁盘队列内部
+type instrumentedTraceClient struct {
+	otlptrace.Client
+	stats *signalStats
+}
+
+func (c *instrumentedTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	start := time.Now()
+	err := c.Client.UploadTraces(ctx, protoSpans)
+	items := 0
+	for _, rs := range protoSpans {
+		for _, ss := range rs.ScopeSpans {
+			items += len(ss.Spans)
+		}
+	}
+	c.stats.record(ctx, items, time.Since(start), err)
+	return err
+}
+
+// instrumentedMetricExporter 统计Metric导出结果和超出基数限制的指标，stats或cardinality为nil时不统计对应的数据，
+// 配置磁盘缓存时统计发送结果的Exporter包装在磁盘队列内部
+type instrumentedMetricExporter struct {
+	metric.Exporter
+	stats       *signalStats
+	cardinality *cardinalityTracker
+}
+
+func (e *instrumentedMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	if e.cardinality != nil {
+		e.cardinality.observe(rm)
+	}
+	start := time.Now()
+	err := e.Exporter.Export(ctx, rm)
+	if e.stats != nil {
+		e.stats.record(ctx, countDataPoints(rm), time.Since(start), err)
+	}
+	return err
+}
+
+// instrumentedLogExporter 统计日志导出结果
+type instrumentedLogExporter struct {
+	sdklog.Exporter
+	stats *signalStats
+}
+
+func (e *instrumentedLogExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	start := time.Now()
+	err := e.Exporter.Export(ctx, records)
+	e.stats.record(ctx, len(records), time.Since(start), err)
+	return err
+}
+
+func countDataPoints(rm *metricdata.ResourceMetrics) int {
+	count := 0
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch a := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				count += len(a.DataPoints)
+			case metricdata.Gauge[float64]:
+				count += len(a.DataPoints)
+			case metricdata.Sum[int64]:
+				count += len(a.DataPoints)
+			case metricdata.Sum[float64]:
+				count += len(a.DataPoints)
+			case metricdata.Histogram[int64]:
+				count += len(a.DataPoints)
+			case metricdata.Histogram[float64]:
+				count += len(a.DataPoints)
+			case metricdata.ExponentialHistogram[int64]:
+				count += len(a.DataPoints)
+			case metricdata.ExponentialHistogram[float64]:
+				count += len(a.DataPoints)
+			case metricdata.Summary:
+				count += len(a.DataPoints)
+			}
+		}
+	}
+	return count
+}
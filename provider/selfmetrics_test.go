@@ -0,0 +1,202 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// fakeTraceClient 模拟服务端，err不为nil时发送失败
+type fakeTraceClient struct {
+	err   error
+	spans int
+}
+
+func (c *fakeTraceClient) Start(context.Context) error { return nil }
+
+func (c *fakeTraceClient) Stop(context.Context) error { return nil }
+
+func (c *fakeTraceClient) UploadTraces(_ context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	if c.err != nil {
+		return c.err
+	}
+	for _, rs := range protoSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans += len(ss.Spans)
+		}
+	}
+	return nil
+}
+
+func testResourceSpans(n int) []*tracepb.ResourceSpans {
+	ss := &tracepb.ScopeSpans{}
+	for i := 0; i < n; i++ {
+		ss.Spans = append(ss.Spans, &tracepb.Span{Name: "span"})
+	}
+	return []*tracepb.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{ss}}}
+}
+
+func TestDiskBufferRecordsRealSends(t *testing.T) {
+	stats := &signalStats{name: "traces"}
+	server := &fakeTraceClient{err: errors.New("unavailable")}
+	client, err := newDiskBufferedTraceClient(&instrumentedTraceClient{Client: server, stats: stats}, t.TempDir(), 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats.diskQueue.Store(client.buffer.queue)
+	defer client.buffer.queue.Close()
+
+	ctx := context.Background()
+	if err := client.UploadTraces(ctx, testResourceSpans(3)); err != nil {
+		t.Fatal(err)
+	}
+	if stats.exported.Load() != 0 || stats.lastSuccess.Load() != 0 {
+		t.Fatalf("writing to the disk buffer was recorded as an export: exported=%d", stats.exported.Load())
+	}
+
+	if err := client.buffer.flush(ctx); err == nil {
+		t.Fatal("flush should fail while the server is unavailable")
+	}
+	if stats.failures.Load() != 1 || stats.failingSince.Load() == 0 {
+		t.Fatalf("failed send was not recorded: failures=%d", stats.failures.Load())
+	}
+	if stats.failedItems.Load() != 0 || stats.diskQueueSize() != 1 {
+		t.Fatalf("spans kept on disk were counted as dropped: dropped=%d queue=%d", stats.failedItems.Load(), stats.diskQueueSize())
+	}
+
+	server.err = nil
+	if err := client.buffer.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stats.exported.Load() != 3 || server.spans != 3 || stats.failingSince.Load() != 0 {
+		t.Fatalf("exported=%d sent=%d failingSince=%d", stats.exported.Load(), server.spans, stats.failingSince.Load())
+	}
+	if stats.diskQueueSize() != 0 {
+		t.Fatalf("disk queue size = %d after a successful send", stats.diskQueueSize())
+	}
+}
+
+// blockingSpanExporter 导出时阻塞，直到release被关闭
+type blockingSpanExporter struct {
+	recordingSpanExporter
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *blockingSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	select {
+	case e.started <- struct{}{}:
+	default:
+	}
+	<-e.release
+	return e.recordingSpanExporter.ExportSpans(ctx, spans)
+}
+
+func TestSpanQueueCountsDrops(t *testing.T) {
+	c, err := NewConfig(WithServiceName("test"), WithTraceExporterEndpoint(""), WithMetricExporterEndpoint(""),
+		WithBatchSpanProcessor(4, 4, time.Hour, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.stats.Store(newPipelineStats(c))
+	exporter := &blockingSpanExporter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	if err := c.initTracer(exporter, func() {}, c); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown(c)
+
+	tracer := otel.Tracer("test")
+	endSpans := func(n int) {
+		for i := 0; i < n; i++ {
+			_, span := tracer.Start(context.Background(), "span")
+			span.End()
+		}
+	}
+	// 第一批导出时阻塞，之后的Span留在队列中，队列满后丢弃
+	endSpans(4)
+	<-exporter.started
+	endSpans(6)
+	queue := c.stats.Load().traces.queue
+	if queue.size.Load() != 4 || queue.dropped.Load() != 2 {
+		t.Fatalf("queue size = %d, dropped = %d, want 4 and 2", queue.size.Load(), queue.dropped.Load())
+	}
+
+	close(exporter.release)
+	forceFlush(t)
+	if got := len(exporter.spans()); got != 8 {
+		t.Fatalf("exported %d spans, want 8", got)
+	}
+	if queue.size.Load() != 0 {
+		t.Fatalf("queue size = %d after flush, want 0", queue.size.Load())
+	}
+	if summary := c.stats.Load().traces.summary(); !strings.Contains(summary, "dropped=2") {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+}
+
+func TestSelfMetricsRegistersDiskQueueSize(t *testing.T) {
+	hasQueueSize := func(stats *pipelineStats) (bool, int64) {
+		meterProvider, reader := newMetricTestProvider(t)
+		if err := stats.register(meterProvider); err != nil {
+			t.Fatal(err)
+		}
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatal(err)
+		}
+		found, queueFull := false, int64(-1)
+		for _, m := range rm.ScopeMetrics[0].Metrics {
+			switch m.Name {
+			case selfMetricPrefix + "queue.size":
+				found = true
+			case selfMetricPrefix + "dropped":
+				for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+					if reason, _ := dp.Attributes.Value("reason"); reason == attribute.StringValue("queue_full") {
+						queueFull = dp.Value
+					}
+				}
+			}
+		}
+		return found, queueFull
+	}
+
+	stats := newPipelineStats(&Config{BSPMaxQueueSize: 1})
+	stats.traces.queue.enqueue()
+	stats.traces.queue.enqueue()
+	if found, queueFull := hasQueueSize(stats); found || queueFull != 1 {
+		t.Fatalf("queue.size registered = %v without disk buffer, queue_full drops = %d", found, queueFull)
+	}
+
+	queue, err := openDiskQueue(t.TempDir(), 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+	stats = newPipelineStats(&Config{})
+	stats.metrics.diskQueue.Store(queue)
+	if found, _ := hasQueueSize(stats); !found {
+		t.Fatal("queue.size not registered with disk buffer")
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceGraphPrefix = "traces.service.graph."
+
+// 依次从这些属性中获取被调用方
+var peerServiceKeys = []attribute.Key{"peer.service", "server.address", "net.peer.name", "http.host"}
+
+// 依次从这些属性中获取调用协议
+var protocolKeys = []attribute.Key{"rpc.system", "messaging.system", "db.system"}
+
+const (
+	// 等待同一次调用另一端Span的时间，超时的Client端按对端属性记录，超时的Server端丢弃
+	serviceGraphWait = 10 * time.Second
+	// 等待配对的Span数量上限，超过时最早的Span按超时处理
+	maxServiceGraphPending = 10000
+)
+
+// serviceGraphEdge 等待配对的一次调用的一端
+type serviceGraphEdge struct {
+	client   bool
+	service  string
+	peer     string
+	protocol string
+	failed   bool
+	// Client端的耗时，单位为毫秒
+	duration    float64
+	spanContext trace.SpanContext
+	expires     time.Time
+}
+
+// serviceGraphKey 标识一次调用，Client端为其SpanID，Server端为其父SpanID
+type serviceGraphKey struct {
+	traceID trace.TraceID
+	spanID  trace.SpanID
+}
+
+// serviceGraphProcessor 根据Client/Producer和Server/Consumer类型的Span生成服务之间的调用关系指标，
+// 同一次调用的两端都在本进程结束时使用Server端的服务名作为被调用方，否则使用Client端Span上的对端属性
+type serviceGraphProcessor struct {
+	limiter    *seriesLimiter
+	wait       time.Duration
+	maxPending int
+	now        func() time.Time
+
+	calls    otelmetric.Int64Counter
+	errors   otelmetric.Int64Counter
+	duration otelmetric.Float64Histogram
+
+	mu      sync.Mutex
+	pending map[serviceGraphKey]*serviceGraphEdge
+	// 按加入顺序排列的key，用于超时和淘汰
+	order []serviceGraphKey
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newServiceGraphProcessor(meterProvider otelmetric.MeterProvider, limit int) (*serviceGraphProcessor, error) {
+	meter := meterProvider.Meter(instrumentationName)
+	calls, err := meter.Int64Counter(serviceGraphPrefix+"calls",
+		otelmetric.WithDescription("Number of calls from client to server"), otelmetric.WithUnit("{call}"))
+	if err != nil {
+		return nil, err
+	}
+	errors, err := meter.Int64Counter(serviceGraphPrefix+"errors",
+		otelmetric.WithDescription("Number of failed calls from client to server"), otelmetric.WithUnit("{call}"))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram(serviceGraphPrefix+"duration",
+		otelmetric.WithDescription("Duration of calls from client to server"), otelmetric.WithUnit("ms"))
+	if err != nil {
+		return nil, err
+	}
+	p := &serviceGraphProcessor{
+		limiter:    newSeriesLimiter("service graph metrics", limit),
+		wait:       serviceGraphWait,
+		maxPending: maxServiceGraphPending,
+		now:        time.Now,
+		calls:      calls,
+		errors:     errors,
+		duration:   duration,
+		pending:    map[serviceGraphKey]*serviceGraphEdge{},
+		done:       make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+// run 定期处理超时未配对的Span
+func (p *serviceGraphProcessor) run() {
+	ticker := time.NewTicker(p.wait)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.expire(false)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *serviceGraphProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p *serviceGraphProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	edge := &serviceGraphEdge{
+		service:     resourceValue(s, semconv.ServiceNameKey),
+		protocol:    spanProtocol(s),
+		failed:      s.Status().Code == codes.Error,
+		spanContext: s.SpanContext(),
+	}
+	var key serviceGraphKey
+	switch s.SpanKind() {
+	case trace.SpanKindClient, trace.SpanKindProducer:
+		edge.client = true
+		edge.peer = peerService(s)
+		edge.duration = float64(s.EndTime().Sub(s.StartTime())) / float64(time.Millisecond)
+		key = serviceGraphKey{traceID: s.SpanContext().TraceID(), spanID: s.SpanContext().SpanID()}
+	case trace.SpanKindServer, trace.SpanKindConsumer:
+		if !s.Parent().IsValid() {
+			return
+		}
+		key = serviceGraphKey{traceID: s.Parent().TraceID(), spanID: s.Parent().SpanID()}
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	other, ok := p.pending[key]
+	if ok {
+		if other.client != edge.client {
+			delete(p.pending, key)
+		}
+		p.mu.Unlock()
+		switch {
+		case other.client == edge.client:
+			// 同一父Span下的多个Server端只与Client端配对一次
+			if edge.client {
+				p.record(edge, nil)
+			}
+		case edge.client:
+			p.record(edge, other)
+		default:
+			p.record(other, edge)
+		}
+		return
+	}
+	edge.expires = p.now().Add(p.wait)
+	p.pending[key] = edge
+	p.order = append(p.order, key)
+	p.mu.Unlock()
+	p.expire(false)
+}
+
+// expire 处理超时或超过数量上限的Span，all为true时处理全部等待中的Span
+func (p *serviceGraphProcessor) expire(all bool) {
+	var expired []*serviceGraphEdge
+	p.mu.Lock()
+	now := p.now()
+	for len(p.order) > 0 {
+		key := p.order[0]
+		edge, ok := p.pending[key]
+		if ok && !all && len(p.pending) <= p.maxPending && !now.After(edge.expires) {
+			break
+		}
+		p.order = p.order[1:]
+		if ok {
+			delete(p.pending, key)
+			expired = append(expired, edge)
+		}
+	}
+	p.mu.Unlock()
+	for _, edge := range expired {
+		// 未配对的Server端由调用方所在的服务记录
+		if edge.client {
+			p.record(edge, nil)
+		}
+	}
+}
+
+// record 记录一次调用，server为nil时使用Client端Span上的对端属性作为被调用方
+func (p *serviceGraphProcessor) record(client, server *serviceGraphEdge) {
+	peer, protocol, failed := client.peer, client.protocol, client.failed
+	if server != nil {
+		peer, failed = server.service, failed || server.failed
+		if protocol == "unknown" {
+			protocol = server.protocol
+		}
+	}
+	clientAttr := attribute.String("client", client.service)
+	set := p.limiter.admit(attribute.NewSet(
+		clientAttr,
+		attribute.String("server", peer),
+		attribute.String("protocol", protocol),
+	), clientAttr)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), client.spanContext)
+	opt := otelmetric.WithAttributeSet(set)
+	p.calls.Add(ctx, 1, opt)
+	if failed {
+		p.errors.Add(ctx, 1, opt)
+	}
+	p.duration.Record(ctx, client.duration, opt)
+}
+
+// Shutdown 记录全部等待配对的Client端Span
+func (p *serviceGraphProcessor) Shutdown(context.Context) error {
+	p.stopOnce.Do(func() { close(p.done) })
+	p.expire(true)
+	return nil
+}
+
+func (p *serviceGraphProcessor) ForceFlush(context.Context) error {
+	p.expire(false)
+	return nil
+}
+
+func (p *serviceGraphProcessor) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+func peerService(s sdktrace.ReadOnlySpan) string {
+	for _, key := range peerServiceKeys {
+		if v, ok := spanAttribute(s, key); ok && v.Emit() != "" {
+			return v.Emit()
+		}
+	}
+	return "unknown"
+}
+
+func spanProtocol(s sdktrace.ReadOnlySpan) string {
+	for _, key := range protocolKeys {
+		if v, ok := spanAttribute(s, key); ok && v.Emit() != "" {
+			return v.Emit()
+		}
+	}
+	for _, key := range []attribute.Key{"http.request.method", "http.method"} {
+		if _, ok := spanAttribute(s, key); ok {
+			return "http"
+		}
+	}
+	return "unknown"
+}
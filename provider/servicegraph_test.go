@@ -0,0 +1,152 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func serviceGraphCalls(points []metricdata.DataPoint[int64]) map[string]int64 {
+	calls := map[string]int64{}
+	for _, dp := range points {
+		client, _ := dp.Attributes.Value("client")
+		server, _ := dp.Attributes.Value("server")
+		protocol, _ := dp.Attributes.Value("protocol")
+		calls[client.Emit()+"->"+server.Emit()+"/"+protocol.Emit()] += dp.Value
+	}
+	return calls
+}
+
+func TestServiceGraphPairsClientAndServer(t *testing.T) {
+	meterProvider, reader := newMetricTestProvider(t)
+	processor, err := newServiceGraphProcessor(meterProvider, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer processor.Shutdown(context.Background())
+	tracer := newProcessorTestTracer(t, processor)
+
+	// Server端先结束，Client端结束时配对，被调用方使用Server端的服务名
+	ctx, client := tracer.Start(context.Background(), "GET /users", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.method", "GET"), attribute.String("server.address", "users.internal")))
+	_, server := tracer.Start(ctx, "GET /users", trace.WithSpanKind(trace.SpanKindServer))
+	server.SetStatus(codes.Error, "failed")
+	server.End()
+	client.End()
+
+	// Producer端先结束，Consumer端结束时配对
+	ctx, producer := tracer.Start(context.Background(), "publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.system", "kafka")))
+	producer.End()
+	_, consumer := tracer.Start(ctx, "process", trace.WithSpanKind(trace.SpanKindConsumer))
+	consumer.End()
+
+	calls := serviceGraphCalls(collectMetric(t, reader, serviceGraphPrefix+"calls").Data.(metricdata.Sum[int64]).DataPoints)
+	if len(calls) != 2 || calls["checkout->checkout/http"] != 1 || calls["checkout->checkout/kafka"] != 1 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	errors := serviceGraphCalls(collectMetric(t, reader, serviceGraphPrefix+"errors").Data.(metricdata.Sum[int64]).DataPoints)
+	if len(errors) != 1 || errors["checkout->checkout/http"] != 1 {
+		t.Fatalf("unexpected errors %v", errors)
+	}
+	duration := collectMetric(t, reader, serviceGraphPrefix+"duration").Data.(metricdata.Histogram[float64])
+	var count uint64
+	for _, dp := range duration.DataPoints {
+		count += dp.Count
+	}
+	if count != 2 {
+		t.Fatalf("duration count = %d, want 2", count)
+	}
+	if processor.len() != 0 {
+		t.Fatalf("%d spans still pending after pairing", processor.len())
+	}
+}
+
+func TestServiceGraphExpiry(t *testing.T) {
+	meterProvider, reader := newMetricTestProvider(t)
+	processor, err := newServiceGraphProcessor(meterProvider, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer processor.Shutdown(context.Background())
+	now := time.Now()
+	processor.now = func() time.Time { return now }
+	tracer := newProcessorTestTracer(t, processor)
+
+	// 调用进程外的服务，Client端等待配对
+	_, client := tracer.Start(context.Background(), "GET /orders", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("peer.service", "orders"), attribute.String("rpc.system", "grpc")))
+	client.End()
+	// 来自进程外调用方的Server端等待配对
+	remote := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}, TraceFlags: trace.FlagsSampled, Remote: true}))
+	_, server := tracer.Start(remote, "GET /checkout", trace.WithSpanKind(trace.SpanKindServer))
+	server.End()
+	if processor.len() != 2 {
+		t.Fatalf("pending = %d, want 2", processor.len())
+	}
+
+	processor.ForceFlush(context.Background())
+	if processor.len() != 2 {
+		t.Fatal("spans expired before the wait time")
+	}
+
+	// 超时后Client端按对端属性记录，Server端丢弃
+	now = now.Add(serviceGraphWait + time.Second)
+	processor.ForceFlush(context.Background())
+	if processor.len() != 0 {
+		t.Fatalf("pending = %d after expiry, want 0", processor.len())
+	}
+	calls := serviceGraphCalls(collectMetric(t, reader, serviceGraphPrefix+"calls").Data.(metricdata.Sum[int64]).DataPoints)
+	if len(calls) != 1 || calls["checkout->orders/grpc"] != 1 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+}
+
+func TestServiceGraphMaxPending(t *testing.T) {
+	meterProvider, reader := newMetricTestProvider(t)
+	processor, err := newServiceGraphProcessor(meterProvider, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer processor.Shutdown(context.Background())
+	processor.maxPending = 2
+	tracer := newProcessorTestTracer(t, processor)
+	for i := 0; i < 3; i++ {
+		_, client := tracer.Start(context.Background(), "call", trace.WithSpanKind(trace.SpanKindClient))
+		client.End()
+	}
+	if processor.len() != 2 {
+		t.Fatalf("pending = %d, want 2", processor.len())
+	}
+	processor.Shutdown(context.Background())
+	calls := serviceGraphCalls(collectMetric(t, reader, serviceGraphPrefix+"calls").Data.(metricdata.Sum[int64]).DataPoints)
+	if calls["checkout->unknown/unknown"] != 3 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+}
+
+func TestServiceGraphRequiresMetricEndpoint(t *testing.T) {
+	if _, err := NewConfig(WithServiceName("test"), WithMetricExporterEndpoint(""), WithServiceGraph(true)); err == nil {
+		t.Fatal("service graph without metric endpoint should be invalid")
+	}
+}
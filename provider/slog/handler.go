@@ -0,0 +1,291 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slog correlates log/slog records with traces: the Handler adds trace_id, span_id and trace_flags
+// of the span in the context to every record, and optionally forwards records to the OpenTelemetry Logs
+// pipeline created by provider.Start
+// 为log/slog日志添加trace_id、span_id和trace_flags，并可以把日志转发到provider创建的OpenTelemetry Logs
+package slog
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider"
+)
+
+// ScopeName is the instrumentation scope name of forwarded records
+const ScopeName = "github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/slog"
+
+// field names of the trace context
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// Option configures the Handler
+type Option func(*Handler)
+
+// WithLoggerProvider forwards records to the OpenTelemetry Logs pipeline of provider, usually config.LoggerProvider()
+// after provider.Start, records are not forwarded when provider is nil
+// 把日志转发到OpenTelemetry Logs，通常传入provider.Start之后的config.LoggerProvider()，为nil时不转发
+func WithLoggerProvider(provider log.LoggerProvider) Option {
+	return func(h *Handler) {
+		if provider != nil {
+			h.logger = provider.Logger(ScopeName)
+		}
+	}
+}
+
+// WithLevel configures the minimum level of records forwarded to OpenTelemetry Logs, defaults to slog.LevelInfo
+// 配置转发到OpenTelemetry Logs的最低日志级别，默认为slog.LevelInfo
+func WithLevel(level slog.Leveler) Option {
+	return func(h *Handler) {
+		h.level = level
+	}
+}
+
+// WithRedactor redacts the message and attributes of forwarded records, usually the redactor returned by config.Redactor(),
+// which is nil when redaction is not enabled, records written to the wrapped handler are not redacted
+// 脱敏转发到OpenTelemetry Logs的日志内容和属性，通常传入config.Redactor()，未开启脱敏时为nil
+func WithRedactor(redactor *provider.Redactor) Option {
+	return func(h *Handler) {
+		h.redactor = redactor
+	}
+}
+
+// group 通过WithGroup打开的分组，attrs和slogAttrs为分组内通过WithAttrs添加的属性，分别用于转发和重建next
+type group struct {
+	name      string
+	attrs     []log.KeyValue
+	slogAttrs []slog.Attr
+}
+
+// Handler wraps a slog.Handler, adding the trace context to records and forwarding them to OpenTelemetry Logs
+// 包装slog.Handler，在日志中添加Trace上下文并转发到OpenTelemetry Logs
+type Handler struct {
+	next     slog.Handler
+	logger   log.Logger
+	level    slog.Leveler
+	redactor *provider.Redactor
+
+	// 打开第一个分组之前的next，用于在顶层添加Trace字段
+	root slog.Handler
+
+	// 转发到OpenTelemetry Logs时使用的属性和分组
+	attrs  []log.KeyValue
+	groups []group
+}
+
+// NewHandler creates a Handler, next may be nil when records are only forwarded to OpenTelemetry Logs
+// 创建Handler，只转发到OpenTelemetry Logs时next可以为nil
+func NewHandler(next slog.Handler, opts ...Option) *Handler {
+	h := &Handler{next: next, level: slog.LevelInfo}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Enabled implements slog.Handler
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next != nil && h.next.Enabled(ctx, level) {
+		return true
+	}
+	if !h.forward(level) {
+		return false
+	}
+	var record log.Record
+	record.SetSeverity(severity(level))
+	return h.logger.Enabled(ctx, record)
+}
+
+// Handle implements slog.Handler, trace fields are added only when ctx contains a valid span context,
+// they are always added at the top level, not in the groups opened by WithGroup
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if h.forward(r.Level) {
+		h.logger.Emit(ctx, h.convertRecord(r))
+	}
+	if h.next == nil || !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return h.next.Handle(ctx, r)
+	}
+	traceAttrs := []slog.Attr{
+		slog.String(TraceIDKey, sc.TraceID().String()),
+		slog.String(SpanIDKey, sc.SpanID().String()),
+		slog.String(TraceFlagsKey, sc.TraceFlags().String()),
+	}
+	if len(h.groups) == 0 {
+		r = r.Clone()
+		r.AddAttrs(traceAttrs...)
+		return h.next.Handle(ctx, r)
+	}
+	// 记录的属性会放在已打开的分组中，需要在打开分组之前添加Trace字段，再重新打开分组
+	next := h.root.WithAttrs(traceAttrs)
+	for _, g := range h.groups {
+		next = next.WithGroup(g.name)
+		if len(g.slogAttrs) > 0 {
+			next = next.WithAttrs(g.slogAttrs)
+		}
+	}
+	return next.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := h.clone()
+	if h.next != nil {
+		clone.next = h.next.WithAttrs(attrs)
+		if n := len(clone.groups); n > 0 {
+			clone.groups[n-1].slogAttrs = append(slices.Clip(clone.groups[n-1].slogAttrs), attrs...)
+		}
+	}
+	if h.logger != nil {
+		kvs := convertAttrs(attrs)
+		if n := len(clone.groups); n > 0 {
+			clone.groups[n-1].attrs = append(slices.Clip(clone.groups[n-1].attrs), kvs...)
+		} else {
+			clone.attrs = append(slices.Clip(clone.attrs), kvs...)
+		}
+	}
+	return clone
+}
+
+// WithGroup implements slog.Handler
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := h.clone()
+	if h.next != nil {
+		clone.next = h.next.WithGroup(name)
+		if len(h.groups) == 0 {
+			clone.root = h.next
+		}
+	}
+	clone.groups = append(slices.Clip(clone.groups), group{name: name})
+	return clone
+}
+
+// forward 是否转发到OpenTelemetry Logs
+func (h *Handler) forward(level slog.Level) bool {
+	return h.logger != nil && level >= h.level.Level()
+}
+
+func (h *Handler) clone() *Handler {
+	clone := *h
+	clone.groups = slices.Clone(h.groups)
+	return &clone
+}
+
+func (h *Handler) convertRecord(r slog.Record) log.Record {
+	var record log.Record
+	record.SetTimestamp(r.Time)
+	message := r.Message
+	if h.redactor != nil {
+		message = h.redactor.RedactString(message)
+	}
+	record.SetBody(log.StringValue(message))
+	record.SetSeverity(severity(r.Level))
+	record.SetSeverityText(r.Level.String())
+
+	kvs := make([]log.KeyValue, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		kvs = appendAttr(kvs, a)
+		return true
+	})
+	// 从最内层的分组开始嵌套，没有属性的分组被忽略
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		kvs = append(slices.Clip(h.groups[i].attrs), kvs...)
+		if len(kvs) > 0 {
+			kvs = []log.KeyValue{log.Map(h.groups[i].name, kvs...)}
+		}
+	}
+	kvs = append(slices.Clip(h.attrs), kvs...)
+	if h.redactor != nil {
+		kvs = h.redactor.RedactLogKeyValues(kvs)
+	}
+	record.AddAttributes(kvs...)
+	return record
+}
+
+// severity 把slog的日志级别转换为OpenTelemetry的日志级别，slog.LevelInfo对应log.SeverityInfo
+func severity(level slog.Level) log.Severity {
+	return log.Severity(level + slog.Level(log.SeverityInfo))
+}
+
+func convertAttrs(attrs []slog.Attr) []log.KeyValue {
+	kvs := make([]log.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		kvs = appendAttr(kvs, a)
+	}
+	return kvs
+}
+
+// appendAttr 按照slog.Handler的约定忽略空属性，展开key为空的分组
+func appendAttr(kvs []log.KeyValue, a slog.Attr) []log.KeyValue {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kvs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := convertAttrs(a.Value.Group())
+		if len(attrs) == 0 {
+			return kvs
+		}
+		if a.Key == "" {
+			return append(kvs, attrs...)
+		}
+		return append(kvs, log.Map(a.Key, attrs...))
+	}
+	return append(kvs, log.KeyValue{Key: a.Key, Value: convertValue(a.Value)})
+}
+
+func convertValue(v slog.Value) log.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return log.StringValue(v.String())
+	case slog.KindInt64:
+		return log.Int64Value(v.Int64())
+	case slog.KindUint64:
+		if v.Uint64() > math.MaxInt64 {
+			return log.StringValue(v.String())
+		}
+		return log.Int64Value(int64(v.Uint64()))
+	case slog.KindFloat64:
+		return log.Float64Value(v.Float64())
+	case slog.KindBool:
+		return log.BoolValue(v.Bool())
+	case slog.KindDuration:
+		return log.Int64Value(v.Duration().Nanoseconds())
+	case slog.KindTime:
+		return log.Int64Value(v.Time().UnixNano())
+	}
+	switch x := v.Any().(type) {
+	case []byte:
+		return log.BytesValue(x)
+	case error:
+		return log.StringValue(x.Error())
+	}
+	return log.StringValue(v.String())
+}
@@ -0,0 +1,147 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/log"
+
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider"
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/internal/logtest"
+)
+
+func TestHandlerAddsTraceContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil))).With("app", "shop").
+		WithGroup("request").With("id", "42").WithGroup("http")
+	ctx, sc := logtest.ContextWithSpan()
+
+	// Trace字段总是在顶层，分组和分组内的属性保持不变
+	logger.InfoContext(ctx, "hello", "path", "/users")
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got[TraceIDKey] != sc.TraceID().String() || got[SpanIDKey] != sc.SpanID().String() ||
+		got[TraceFlagsKey] != sc.TraceFlags().String() || got["app"] != "shop" {
+		t.Fatalf("unexpected record %s", buf.String())
+	}
+	request, _ := got["request"].(map[string]any)
+	http, _ := request["http"].(map[string]any)
+	if len(request) != 2 || request["id"] != "42" || len(http) != 1 || http["path"] != "/users" {
+		t.Fatalf("unexpected groups %s", buf.String())
+	}
+
+	buf.Reset()
+	logger.Info("no span")
+	if bytes.Contains(buf.Bytes(), []byte(TraceIDKey)) {
+		t.Fatalf("trace fields added without span context: %s", buf.String())
+	}
+}
+
+func TestHandlerForwardsRecords(t *testing.T) {
+	provider, exporter := logtest.NewLoggerProvider(t)
+	logger := slog.New(NewHandler(nil, WithLoggerProvider(provider)))
+	logger = logger.With("service", "demo").WithGroup("http").With("method", "GET")
+
+	logger.Debug("filtered")
+	logger.Warn("slow request", "status", 200, slog.Group("", "inline", true), slog.Group("empty"))
+
+	if len(exporter.Records()) != 1 {
+		t.Fatalf("exported %d records, want 1", len(exporter.Records()))
+	}
+	r := exporter.Records()[0]
+	if r.Body().AsString() != "slow request" || r.Severity() != log.SeverityWarn || r.SeverityText() != "WARN" {
+		t.Fatalf("body = %v, severity = %v, severity text = %q", r.Body(), r.Severity(), r.SeverityText())
+	}
+	attrs := logtest.Attributes(r)
+	if attrs["service"].AsString() != "demo" || len(attrs) != 2 {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+	httpAttrs := map[string]log.Value{}
+	for _, kv := range attrs["http"].AsMap() {
+		httpAttrs[kv.Key] = kv.Value
+	}
+	if httpAttrs["method"].AsString() != "GET" || httpAttrs["status"].AsInt64() != 200 || !httpAttrs["inline"].AsBool() || len(httpAttrs) != 3 {
+		t.Fatalf("unexpected http group %v", httpAttrs)
+	}
+}
+
+func TestHandlerLevel(t *testing.T) {
+	provider, exporter := logtest.NewLoggerProvider(t)
+	h := NewHandler(nil, WithLoggerProvider(provider), WithLevel(slog.LevelError))
+	ctx := context.Background()
+	if h.Enabled(ctx, slog.LevelWarn) || !h.Enabled(ctx, slog.LevelError) {
+		t.Fatal("Enabled should follow the configured level")
+	}
+	logger := slog.New(h)
+	logger.Warn("warn")
+	logger.Error("error")
+	if len(exporter.Records()) != 1 || exporter.Records()[0].Severity() != log.SeverityError {
+		t.Fatalf("unexpected records %v", exporter.Records())
+	}
+
+	// 没有LoggerProvider时只输出到next
+	var buf bytes.Buffer
+	h = NewHandler(slog.NewTextHandler(&buf, nil), WithLoggerProvider(nil))
+	if h.Enabled(ctx, slog.LevelDebug) || !h.Enabled(ctx, slog.LevelInfo) {
+		t.Fatal("Enabled should follow the next handler")
+	}
+	slog.New(h).Info("text")
+	if buf.Len() == 0 {
+		t.Fatal("record not written to next handler")
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for level, want := range map[slog.Level]log.Severity{
+		slog.LevelDebug: log.SeverityDebug,
+		slog.LevelInfo:  log.SeverityInfo,
+		slog.LevelWarn:  log.SeverityWarn,
+		slog.LevelError: log.SeverityError,
+	} {
+		if got := severity(level); got != want {
+			t.Fatalf("severity(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestHandlerRedactsForwardedRecords(t *testing.T) {
+	redactor, err := provider.NewRedactor([]string{"password"}, []string{provider.RedactionPatternCNMobile}, provider.RedactionModeMask, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggerProvider, exporter := logtest.NewLoggerProvider(t)
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, nil), WithLoggerProvider(loggerProvider), WithRedactor(redactor)))
+	logger.With("password", "secret").WithGroup("user").Info("call +8613812345678", "phone", "13812345678")
+
+	r := exporter.Records()[0]
+	if got := r.Body().AsString(); got != "call ****" {
+		t.Fatalf("body = %q", got)
+	}
+	attrs := logtest.Attributes(r)
+	if attrs["password"].AsString() != "****" || attrs["user"].AsMap()[0].Value.AsString() != "****" {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("13812345678")) {
+		t.Fatalf("record written to next handler should not be redacted: %s", buf.String())
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingSpanExporter 记录每次导出的Span
+type recordingSpanExporter struct {
+	mu      sync.Mutex
+	batches [][]sdktrace.ReadOnlySpan
+}
+
+func (e *recordingSpanExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches = append(e.batches, spans)
+	return nil
+}
+
+func (e *recordingSpanExporter) Shutdown(context.Context) error { return nil }
+
+func (e *recordingSpanExporter) spans() []sdktrace.ReadOnlySpan {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var spans []sdktrace.ReadOnlySpan
+	for _, batch := range e.batches {
+		spans = append(spans, batch...)
+	}
+	return spans
+}
+
+// startTestTracer 使用opts创建配置，并以exporter初始化全局的TracerProvider
+func startTestTracer(t *testing.T, exporter sdktrace.SpanExporter, opts ...Option) *Config {
+	t.Helper()
+	opts = append([]Option{WithServiceName("test"), WithTraceExporterEndpoint(""), WithMetricExporterEndpoint("")}, opts...)
+	c, err := NewConfig(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.initTracer(exporter, func() {}, c); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Shutdown(c) })
+	return c
+}
+
+func forceFlush(t *testing.T) {
+	t.Helper()
+	if err := otel.GetTracerProvider().(flusher).ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchSpanProcessorRespectsEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantMax int
+	}{
+		{"env", nil, 2},
+		{"explicit option", []Option{WithBatchSpanProcessor(0, 3, 0, 0)}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_BSP_MAX_EXPORT_BATCH_SIZE", "2")
+			exporter := &recordingSpanExporter{}
+			startTestTracer(t, exporter, tt.opts...)
+			tracer := otel.Tracer("test")
+			for i := 0; i < 7; i++ {
+				_, span := tracer.Start(context.Background(), "span")
+				span.End()
+			}
+			forceFlush(t)
+
+			maxBatch := 0
+			for _, batch := range exporter.batches {
+				maxBatch = max(maxBatch, len(batch))
+			}
+			if len(exporter.spans()) != 7 || maxBatch != tt.wantMax {
+				t.Fatalf("exported %d spans with max batch %d, want 7 spans with max batch %d", len(exporter.spans()), maxBatch, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestSpanLimitsDefaults(t *testing.T) {
+	if got, want := (&Config{}).spanLimits(), sdktrace.NewSpanLimits(); got != want {
+		t.Fatalf("span limits of an empty config = %+v, want %+v", got, want)
+	}
+	c := &Config{SpanLimits: sdktrace.SpanLimits{AttributeCountLimit: 2, LinkCountLimit: -1}}
+	want := sdktrace.NewSpanLimits()
+	want.AttributeCountLimit, want.LinkCountLimit = 2, -1
+	if got := c.spanLimits(); got != want {
+		t.Fatalf("span limits = %+v, want %+v", got, want)
+	}
+}
+
+func TestPartialSpanLimits(t *testing.T) {
+	exporter := &recordingSpanExporter{}
+	startTestTracer(t, exporter, WithSpanLimits(sdktrace.SpanLimits{AttributeCountLimit: 2}))
+
+	_, span := otel.Tracer("test").Start(context.Background(), "span",
+		trace.WithAttributes(attribute.String("a", "1"), attribute.String("b", "2"), attribute.String("c", "3")),
+		trace.WithLinks(trace.Link{Attributes: []attribute.KeyValue{attribute.String("l", "1")}}))
+	span.AddEvent("event", trace.WithAttributes(attribute.String("e", "1")))
+	span.End()
+	forceFlush(t)
+
+	s := exporter.spans()[0]
+	if len(s.Attributes()) != 2 || len(s.Events()) != 1 || len(s.Links()) != 1 {
+		t.Fatalf("attributes=%d events=%d links=%d, want 2, 1, 1", len(s.Attributes()), len(s.Events()), len(s.Links()))
+	}
+	if len(s.Events()[0].Attributes) != 1 || len(s.Links()[0].Attributes) != 1 {
+		t.Fatal("event or link attributes were dropped")
+	}
+}
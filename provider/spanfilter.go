@@ -0,0 +1,281 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var spanKinds = map[string]trace.SpanKind{
+	"internal": trace.SpanKindInternal,
+	"server":   trace.SpanKindServer,
+	"client":   trace.SpanKindClient,
+	"producer": trace.SpanKindProducer,
+	"consumer": trace.SpanKindConsumer,
+}
+
+// SpanFilterRule drops spans matching all of its non-empty conditions before export,
+// SpanName, Scope and attribute values support * and ? wildcards
+// Span过滤规则，所有非空条件都匹配的Span不会被导出，SpanName、Scope和属性值支持*和?通配符
+type SpanFilterRule struct {
+	SpanName string `json:"span_name"`
+	// internal, server, client, producer, consumer
+	SpanKind string `json:"span_kind"`
+	// 创建Span的instrumentation scope名称，例如go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp
+	Scope string `json:"scope"`
+	// 属性值匹配，例如 {"http.target": "/healthz"}
+	Attributes map[string]string `json:"attributes"`
+	// 为true时同时丢弃同一Trace中在该Span之后开始的所有Span，包括父Span结束后才开始的子Span，此时只匹配创建Span时设置的属性
+	DropTrace bool `json:"drop_trace"`
+}
+
+type compiledSpanFilterRule struct {
+	name       *regexp.Regexp
+	kind       trace.SpanKind
+	scope      *regexp.Regexp
+	attributes map[attribute.Key]*regexp.Regexp
+	dropTrace  bool
+}
+
+// compileWildcard 把*和?通配符转换为正则表达式，空字符串返回nil
+func compileWildcard(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.MustCompile("^" + quoted + "$")
+}
+
+func (r SpanFilterRule) compile() (*compiledSpanFilterRule, error) {
+	if r.SpanName == "" && r.SpanKind == "" && r.Scope == "" && len(r.Attributes) == 0 {
+		return nil, errors.New("span_name, span_kind, scope or attributes is required")
+	}
+	rule := &compiledSpanFilterRule{
+		name:       compileWildcard(r.SpanName),
+		scope:      compileWildcard(r.Scope),
+		attributes: map[attribute.Key]*regexp.Regexp{},
+		dropTrace:  r.DropTrace,
+	}
+	if r.SpanKind != "" {
+		kind, ok := spanKinds[r.SpanKind]
+		if !ok {
+			return nil, fmt.Errorf("unknown span kind %q", r.SpanKind)
+		}
+		rule.kind = kind
+	}
+	for key, value := range r.Attributes {
+		rule.attributes[attribute.Key(key)] = compileWildcard(value)
+	}
+	return rule, nil
+}
+
+func (r *compiledSpanFilterRule) match(name string, kind trace.SpanKind, scope instrumentation.Scope, attrs []attribute.KeyValue) bool {
+	if r.name != nil && !r.name.MatchString(name) {
+		return false
+	}
+	if r.kind != trace.SpanKindUnspecified && r.kind != kind {
+		return false
+	}
+	if r.scope != nil && !r.scope.MatchString(scope.Name) {
+		return false
+	}
+	for key, value := range r.attributes {
+		matched := false
+		for _, kv := range attrs {
+			if kv.Key == key {
+				matched = value == nil || value.MatchString(kv.Value.Emit())
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// loadSpanFilterRules 从JSON文件中读取过滤规则，文件内容为SpanFilterRule数组
+func loadSpanFilterRules(path string) ([]SpanFilterRule, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []SpanFilterRule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("parse span filter file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+func compileSpanFilterRules(rules []SpanFilterRule) ([]*compiledSpanFilterRule, error) {
+	compiled := make([]*compiledSpanFilterRule, 0, len(rules))
+	for i, r := range rules {
+		rule, err := r.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid span filter rule #%d: %w", i, err)
+		}
+		compiled = append(compiled, rule)
+	}
+	return compiled, nil
+}
+
+const (
+	// 被丢弃的Trace在最后一个Span开始或结束后保留的时间
+	droppedTraceTTL = 10 * time.Minute
+	// 同时记录的被丢弃Trace的最大数量，超过时淘汰最早记录的Trace
+	maxDroppedTraces = 4096
+)
+
+type droppedTrace struct {
+	// 匹配drop_trace规则的Span开始的时间，之后开始的Span都会被丢弃
+	since   time.Time
+	expires time.Time
+}
+
+// droppedTraces 按TraceID记录被丢弃的Trace，过期或超过数量上限的记录会被淘汰
+type droppedTraces struct {
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time
+
+	mu     sync.Mutex
+	traces map[trace.TraceID]*droppedTrace
+	// 按记录顺序排列的TraceID，用于淘汰
+	order []trace.TraceID
+}
+
+func newDroppedTraces(ttl time.Duration, maxSize int) *droppedTraces {
+	return &droppedTraces{ttl: ttl, maxSize: maxSize, now: time.Now, traces: map[trace.TraceID]*droppedTrace{}}
+}
+
+// add 记录从start开始丢弃traceID的Span，已记录且未过期时只延长保留时间
+func (d *droppedTraces) add(traceID trace.TraceID, start time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	if t, ok := d.traces[traceID]; ok {
+		if now.After(t.expires) || start.Before(t.since) {
+			t.since = start
+		}
+		t.expires = now.Add(d.ttl)
+		return
+	}
+	for len(d.order) > 0 {
+		oldest := d.order[0]
+		if len(d.order) < d.maxSize && !now.After(d.traces[oldest].expires) {
+			break
+		}
+		delete(d.traces, oldest)
+		d.order = d.order[1:]
+	}
+	d.traces[traceID] = &droppedTrace{since: start, expires: now.Add(d.ttl)}
+	d.order = append(d.order, traceID)
+}
+
+// contains 判断在start开始的Span是否属于被丢弃的Trace，命中时延长保留时间
+func (d *droppedTraces) contains(traceID trace.TraceID, start time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t, ok := d.traces[traceID]
+	now := d.now()
+	if !ok || now.After(t.expires) || start.Before(t.since) {
+		return false
+	}
+	t.expires = now.Add(d.ttl)
+	return true
+}
+
+func (d *droppedTraces) len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.traces)
+}
+
+// spanFilterProcessor 丢弃匹配规则的Span，其余Span交给processors处理
+type spanFilterProcessor struct {
+	rules      []*compiledSpanFilterRule
+	processors []sdktrace.SpanProcessor
+
+	dropped *droppedTraces
+}
+
+func newSpanFilterProcessor(rules []*compiledSpanFilterRule, processors ...sdktrace.SpanProcessor) *spanFilterProcessor {
+	return &spanFilterProcessor{rules: rules, processors: processors, dropped: newDroppedTraces(droppedTraceTTL, maxDroppedTraces)}
+}
+
+func (p *spanFilterProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	traceID := s.SpanContext().TraceID()
+	if p.dropped.contains(traceID, s.StartTime()) {
+		return
+	}
+	for _, rule := range p.rules {
+		if rule.dropTrace && rule.match(s.Name(), s.SpanKind(), s.InstrumentationScope(), s.Attributes()) {
+			p.dropped.add(traceID, s.StartTime())
+			return
+		}
+	}
+	for _, sp := range p.processors {
+		sp.OnStart(ctx, s)
+	}
+}
+
+func (p *spanFilterProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if p.dropped.contains(s.SpanContext().TraceID(), s.StartTime()) {
+		return
+	}
+	for _, rule := range p.rules {
+		if !rule.dropTrace && rule.match(s.Name(), s.SpanKind(), s.InstrumentationScope(), s.Attributes()) {
+			return
+		}
+	}
+	for _, sp := range p.processors {
+		sp.OnEnd(s)
+	}
+}
+
+func (p *spanFilterProcessor) Shutdown(ctx context.Context) error {
+	var err error
+	for _, sp := range p.processors {
+		if e := sp.Shutdown(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (p *spanFilterProcessor) ForceFlush(ctx context.Context) error {
+	var err error
+	for _, sp := range p.processors {
+		if e := sp.ForceFlush(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanNames(exporter *recordingSpanExporter) []string {
+	var names []string
+	for _, s := range exporter.spans() {
+		names = append(names, s.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func assertSpanNames(t *testing.T, exporter *recordingSpanExporter, want ...string) {
+	t.Helper()
+	got := spanNames(exporter)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("exported spans = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("exported spans = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLoadSpanFilterRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	content := `[{"span_name":"GET /healthz","span_kind":"server","drop_trace":true},{"attributes":{"http.target":"/metrics"}}]`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadSpanFilterRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].SpanName != "GET /healthz" || rules[0].SpanKind != "server" || !rules[0].DropTrace ||
+		rules[1].Attributes["http.target"] != "/metrics" {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+
+	if err := os.WriteFile(path, []byte(`{`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSpanFilterRules(path); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+	if _, err := compileSpanFilterRules([]SpanFilterRule{{SpanKind: "unknown"}}); err == nil {
+		t.Fatal("expected error for unknown span kind")
+	}
+	if _, err := compileSpanFilterRules([]SpanFilterRule{{}}); err == nil {
+		t.Fatal("expected error for empty rule")
+	}
+}
+
+func TestSpanFilterMatch(t *testing.T) {
+	exporter := &recordingSpanExporter{}
+	startTestTracer(t, exporter, WithSpanFilterRules(
+		SpanFilterRule{SpanName: "GET /health*"},
+		SpanFilterRule{SpanName: "poll-?", SpanKind: "client"},
+		SpanFilterRule{Attributes: map[string]string{"http.target": "/metrics*"}},
+	))
+	tracer := otel.Tracer("test")
+	ctx := context.Background()
+	for _, s := range []struct {
+		name  string
+		kind  trace.SpanKind
+		attrs []attribute.KeyValue
+	}{
+		{name: "GET /healthz"},
+		{name: "poll-1", kind: trace.SpanKindClient},
+		{name: "poll-2", kind: trace.SpanKindServer},
+		{name: "GET /metrics", attrs: []attribute.KeyValue{attribute.String("http.target", "/metrics/prom")}},
+		{name: "GET /users", attrs: []attribute.KeyValue{attribute.String("http.target", "/users")}},
+	} {
+		_, span := tracer.Start(ctx, s.name, trace.WithSpanKind(s.kind), trace.WithAttributes(s.attrs...))
+		span.End()
+	}
+	forceFlush(t)
+	assertSpanNames(t, exporter, "poll-2", "GET /users")
+}
+
+func TestSpanFilterDropTrace(t *testing.T) {
+	exporter := &recordingSpanExporter{}
+	startTestTracer(t, exporter, WithSpanFilterRules(SpanFilterRule{SpanName: "GET /healthz", DropTrace: true}))
+	tracer := otel.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "GET /healthz")
+	childCtx, child := tracer.Start(ctx, "check-db")
+	root.End()
+	// 根Span结束后才开始的子Span同样被丢弃
+	_, late := tracer.Start(childCtx, "query")
+	late.End()
+	child.End()
+
+	otherCtx, other := tracer.Start(context.Background(), "GET /users")
+	_, otherChild := tracer.Start(otherCtx, "query")
+	otherChild.End()
+	other.End()
+
+	forceFlush(t)
+	assertSpanNames(t, exporter, "GET /users", "query")
+}
+
+func TestDroppedTracesBounded(t *testing.T) {
+	now := time.Unix(1000, 0)
+	d := newDroppedTraces(time.Minute, 3)
+	d.now = func() time.Time { return now }
+	traceID := func(i byte) trace.TraceID { return trace.TraceID{i} }
+
+	for i := byte(1); i <= 5; i++ {
+		d.add(traceID(i), now)
+	}
+	if n := d.len(); n != 3 {
+		t.Fatalf("len = %d, want 3", n)
+	}
+	if d.contains(traceID(1), now) || d.contains(traceID(2), now) {
+		t.Fatal("oldest traces should be evicted")
+	}
+	if !d.contains(traceID(5), now) {
+		t.Fatal("newest trace should be kept")
+	}
+	if d.contains(traceID(5), now.Add(-time.Second)) {
+		t.Fatal("spans started before the matching span should not be dropped")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if d.contains(traceID(5), now) {
+		t.Fatal("expired trace should not be dropped")
+	}
+	d.add(traceID(6), now)
+	if n := d.len(); n != 1 {
+		t.Fatalf("len = %d after expiry, want 1", n)
+	}
+}
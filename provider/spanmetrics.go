@@ -0,0 +1,147 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const spanMetricsPrefix = "traces.span.metrics."
+
+// seriesLimiter 限制指标的属性组合数，超出后合并到otel.metric.overflow=true的序列中
+type seriesLimiter struct {
+	name  string
+	limit int
+
+	mu       sync.Mutex
+	series   map[attribute.Distinct]struct{}
+	reported bool
+}
+
+func newSeriesLimiter(name string, limit int) *seriesLimiter {
+	return &seriesLimiter{name: name, limit: limit, series: map[attribute.Distinct]struct{}{}}
+}
+
+// admit 返回实际记录时使用的属性，keep为超出限制时保留的属性
+func (l *seriesLimiter) admit(set attribute.Set, keep ...attribute.KeyValue) attribute.Set {
+	if l.limit <= 0 {
+		return set
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.series[set.Equivalent()]; ok || len(l.series) < l.limit {
+		l.series[set.Equivalent()] = struct{}{}
+		return set
+	}
+	if !l.reported {
+		l.reported = true
+		otel.Handle(fmt.Errorf("%s exceeded the cardinality limit %d, new attribute sets are recorded as %s=true",
+			l.name, l.limit, overflowAttribute))
+	}
+	return attribute.NewSet(append(keep, overflowAttribute.Bool(true))...)
+}
+
+// spanMetricsProcessor 根据结束的Span生成请求数、错误数和耗时指标，未采样的Span也会被统计
+type spanMetricsProcessor struct {
+	dimensions []attribute.Key
+	limiter    *seriesLimiter
+
+	calls    otelmetric.Int64Counter
+	duration otelmetric.Float64Histogram
+}
+
+func newSpanMetricsProcessor(meterProvider otelmetric.MeterProvider, dimensions []string, limit int) (*spanMetricsProcessor, error) {
+	meter := meterProvider.Meter(instrumentationName)
+	calls, err := meter.Int64Counter(spanMetricsPrefix+"calls",
+		otelmetric.WithDescription("Number of finished spans, spans with status.code STATUS_CODE_ERROR are errors"), otelmetric.WithUnit("{call}"))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram(spanMetricsPrefix+"duration",
+		otelmetric.WithDescription("Duration of finished spans"), otelmetric.WithUnit("ms"))
+	if err != nil {
+		return nil, err
+	}
+	return &spanMetricsProcessor{
+		dimensions: attributeKeys(dimensions),
+		limiter:    newSeriesLimiter("span metrics", limit),
+		calls:      calls,
+		duration:   duration,
+	}, nil
+}
+
+func (p *spanMetricsProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p *spanMetricsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	serviceName := attribute.String(string(semconv.ServiceNameKey), resourceValue(s, semconv.ServiceNameKey))
+	attrs := []attribute.KeyValue{
+		serviceName,
+		attribute.String("span.name", s.Name()),
+		attribute.String("span.kind", spanKindName(s.SpanKind())),
+		attribute.String("status.code", "STATUS_CODE_"+strings.ToUpper(s.Status().Code.String())),
+	}
+	for _, key := range p.dimensions {
+		if v, ok := spanAttribute(s, key); ok {
+			attrs = append(attrs, attribute.KeyValue{Key: key, Value: v})
+		}
+	}
+	set := p.limiter.admit(attribute.NewSet(attrs...), serviceName)
+
+	// 带上SpanContext，被采样的Span可以作为exemplar
+	ctx := trace.ContextWithSpanContext(context.Background(), s.SpanContext())
+	opt := otelmetric.WithAttributeSet(set)
+	p.calls.Add(ctx, 1, opt)
+	p.duration.Record(ctx, float64(s.EndTime().Sub(s.StartTime()))/float64(time.Millisecond), opt)
+}
+
+func (p *spanMetricsProcessor) Shutdown(context.Context) error { return nil }
+
+func (p *spanMetricsProcessor) ForceFlush(context.Context) error { return nil }
+
+func spanKindName(kind trace.SpanKind) string {
+	return "SPAN_KIND_" + strings.ToUpper(kind.String())
+}
+
+// spanAttribute 依次从Span和Resource的属性中查找key
+func spanAttribute(s sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	if s.Resource() != nil {
+		return s.Resource().Set().Value(key)
+	}
+	return attribute.Value{}, false
+}
+
+func resourceValue(s sdktrace.ReadOnlySpan, key attribute.Key) string {
+	if s.Resource() == nil {
+		return ""
+	}
+	v, _ := s.Resource().Set().Value(key)
+	return v.Emit()
+}
@@ -0,0 +1,145 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newMetricTestProvider 创建使用ManualReader的MeterProvider，用于读取生成的指标
+func newMetricTestProvider(t *testing.T) (*metric.MeterProvider, *metric.ManualReader) {
+	t.Helper()
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	t.Cleanup(func() { meterProvider.Shutdown(context.Background()) })
+	return meterProvider, reader
+}
+
+// newProcessorTestTracer 创建只记录不采样的TracerProvider，未采样的Span同样会交给processor
+func newProcessorTestTracer(t *testing.T, processor sdktrace.SpanProcessor) trace.Tracer {
+	t.Helper()
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(recordOnlySampler{sdktrace.NeverSample()}),
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "checkout"), attribute.String("env", "prod"))))
+	t.Cleanup(func() { tracerProvider.Shutdown(context.Background()) })
+	return tracerProvider.Tracer("test")
+}
+
+// collectMetric 读取名称为name的指标，不存在时测试失败
+func collectMetric(t *testing.T, reader *metric.ManualReader, name string) metricdata.Metrics {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return metricdata.Metrics{}
+}
+
+func sumByAttributes(points []metricdata.DataPoint[int64]) map[attribute.Distinct]int64 {
+	values := map[attribute.Distinct]int64{}
+	for _, dp := range points {
+		values[dp.Attributes.Equivalent()] = dp.Value
+	}
+	return values
+}
+
+func TestSpanMetrics(t *testing.T) {
+	meterProvider, reader := newMetricTestProvider(t)
+	processor, err := newSpanMetricsProcessor(meterProvider, []string{"http.method", "env"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := newProcessorTestTracer(t, processor)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, span := tracer.Start(ctx, "GET /users", trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.method", "GET")))
+		span.End()
+	}
+	_, span := tracer.Start(ctx, "GET /users", trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("http.method", "GET")))
+	span.SetStatus(codes.Error, "failed")
+	span.End()
+
+	base := []attribute.KeyValue{
+		attribute.String("service.name", "checkout"),
+		attribute.String("span.name", "GET /users"),
+		attribute.String("span.kind", "SPAN_KIND_SERVER"),
+		attribute.String("http.method", "GET"),
+		attribute.String("env", "prod"),
+	}
+	ok := attribute.NewSet(append(base, attribute.String("status.code", "STATUS_CODE_UNSET"))...)
+	failed := attribute.NewSet(append(base, attribute.String("status.code", "STATUS_CODE_ERROR"))...)
+
+	calls := collectMetric(t, reader, spanMetricsPrefix+"calls").Data.(metricdata.Sum[int64])
+	values := sumByAttributes(calls.DataPoints)
+	if len(values) != 2 || values[ok.Equivalent()] != 2 || values[failed.Equivalent()] != 1 {
+		t.Fatalf("unexpected calls %+v", calls.DataPoints)
+	}
+	duration := collectMetric(t, reader, spanMetricsPrefix+"duration").Data.(metricdata.Histogram[float64])
+	var count uint64
+	for _, dp := range duration.DataPoints {
+		count += dp.Count
+	}
+	if count != 3 {
+		t.Fatalf("duration count = %d, want 3", count)
+	}
+}
+
+func TestSpanMetricsCardinalityLimit(t *testing.T) {
+	meterProvider, reader := newMetricTestProvider(t)
+	processor, err := newSpanMetricsProcessor(meterProvider, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := newProcessorTestTracer(t, processor)
+	for _, name := range []string{"a", "b", "c"} {
+		_, span := tracer.Start(context.Background(), name)
+		span.End()
+	}
+	calls := collectMetric(t, reader, spanMetricsPrefix+"calls").Data.(metricdata.Sum[int64])
+	overflow := attribute.NewSet(attribute.String("service.name", "checkout"), overflowAttribute.Bool(true))
+	values := sumByAttributes(calls.DataPoints)
+	if len(values) != 2 || values[overflow.Equivalent()] != 2 {
+		t.Fatalf("unexpected calls %+v", calls.DataPoints)
+	}
+}
+
+func TestSpanMetricsRequireMetricEndpoint(t *testing.T) {
+	if _, err := NewConfig(WithServiceName("test"), WithMetricExporterEndpoint(""), WithSpanMetrics()); err == nil {
+		t.Fatal("span metrics without metric endpoint should be invalid")
+	}
+	if _, err := NewConfig(WithServiceName("test"), WithSpanMetrics()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// Temporality 指标的时间性，与OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE的取值一致
+type Temporality string
+
+const (
+	// TemporalityCumulative 所有指标都上报累计值
+	TemporalityCumulative Temporality = "cumulative"
+	// TemporalityDelta Counter、ObservableCounter和Histogram上报增量值，UpDownCounter上报累计值
+	TemporalityDelta Temporality = "delta"
+	// TemporalityLowMemory Counter和Histogram上报增量值，异步的Counter上报累计值，减少SDK的内存占用
+	TemporalityLowMemory Temporality = "lowmemory"
+)
+
+func (t Temporality) validate() error {
+	switch t {
+	case TemporalityCumulative, TemporalityDelta, TemporalityLowMemory:
+		return nil
+	}
+	return fmt.Errorf("unknown metric temporality %q, must be one of cumulative, delta, lowmemory", string(t))
+}
+
+// temporalitySelector 根据配置返回各类指标的时间性
+func (c *Config) temporalitySelector(kind metric.InstrumentKind) metricdata.Temporality {
+	switch c.MetricTemporality {
+	case TemporalityDelta:
+		switch kind {
+		case metric.InstrumentKindCounter, metric.InstrumentKindHistogram, metric.InstrumentKindObservableCounter:
+			return metricdata.DeltaTemporality
+		}
+	case TemporalityLowMemory:
+		switch kind {
+		case metric.InstrumentKindCounter, metric.InstrumentKindHistogram:
+			return metricdata.DeltaTemporality
+		}
+	}
+	return metricdata.CumulativeTemporality
+}
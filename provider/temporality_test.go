@@ -0,0 +1,138 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricTemporalityConfig(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    Temporality
+		wantErr bool
+	}{
+		{env: "", want: TemporalityCumulative},
+		{env: "cumulative", want: TemporalityCumulative},
+		{env: "delta", want: TemporalityDelta},
+		{env: "lowmemory", want: TemporalityLowMemory},
+		{env: "Delta", wantErr: true},
+		{env: "low_memory", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			if tt.env == "" {
+				unsetEnv(t, "SLS_OTEL_METRIC_TEMPORALITY")
+			} else {
+				t.Setenv("SLS_OTEL_METRIC_TEMPORALITY", tt.env)
+			}
+			c, err := NewConfig(WithServiceName("test"))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("temporality %q should be invalid", tt.env)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.MetricTemporality != tt.want {
+				t.Fatalf("temporality = %q, want %q", c.MetricTemporality, tt.want)
+			}
+		})
+	}
+
+	// Option优先于环境变量
+	t.Setenv("SLS_OTEL_METRIC_TEMPORALITY", "delta")
+	c, err := NewConfig(WithServiceName("test"), WithMetricTemporality(TemporalityLowMemory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MetricTemporality != TemporalityLowMemory {
+		t.Fatalf("temporality = %q, want lowmemory", c.MetricTemporality)
+	}
+}
+
+func TestTemporalitySelector(t *testing.T) {
+	const (
+		cumulative = metricdata.CumulativeTemporality
+		delta      = metricdata.DeltaTemporality
+	)
+	kinds := []metric.InstrumentKind{
+		metric.InstrumentKindCounter,
+		metric.InstrumentKindUpDownCounter,
+		metric.InstrumentKindHistogram,
+		metric.InstrumentKindGauge,
+		metric.InstrumentKindObservableCounter,
+		metric.InstrumentKindObservableUpDownCounter,
+		metric.InstrumentKindObservableGauge,
+	}
+	tests := []struct {
+		temporality Temporality
+		want        []metricdata.Temporality
+	}{
+		{TemporalityCumulative, []metricdata.Temporality{cumulative, cumulative, cumulative, cumulative, cumulative, cumulative, cumulative}},
+		{TemporalityDelta, []metricdata.Temporality{delta, cumulative, delta, cumulative, delta, cumulative, cumulative}},
+		{TemporalityLowMemory, []metricdata.Temporality{delta, cumulative, delta, cumulative, cumulative, cumulative, cumulative}},
+	}
+	for _, tt := range tests {
+		c := &Config{MetricTemporality: tt.temporality}
+		for i, kind := range kinds {
+			if got := c.temporalitySelector(kind); got != tt.want[i] {
+				t.Errorf("%s: temporality of %v = %v, want %v", tt.temporality, kind, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestTemporalitySelectorExport(t *testing.T) {
+	c := &Config{MetricTemporality: TemporalityDelta}
+	reader := metric.NewManualReader(metric.WithTemporalitySelector(c.temporalitySelector))
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer meterProvider.Shutdown(context.Background())
+	meter := meterProvider.Meter("app")
+	requests, _ := meter.Int64Counter("requests")
+	inflight, _ := meter.Int64UpDownCounter("inflight")
+
+	collect := func() map[string]metricdata.Sum[int64] {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatal(err)
+		}
+		sums := map[string]metricdata.Sum[int64]{}
+		for _, m := range rm.ScopeMetrics[0].Metrics {
+			sums[m.Name] = m.Data.(metricdata.Sum[int64])
+		}
+		return sums
+	}
+	ctx := context.Background()
+	requests.Add(ctx, 2)
+	inflight.Add(ctx, 2)
+	collect()
+	requests.Add(ctx, 3)
+	inflight.Add(ctx, 3)
+	// Counter上报两次采集之间的增量，UpDownCounter上报累计值
+	sums := collect()
+	if s := sums["requests"]; s.Temporality != metricdata.DeltaTemporality || s.DataPoints[0].Value != 3 {
+		t.Errorf("requests = %+v, want delta 3", s)
+	}
+	if s := sums["inflight"]; s.Temporality != metricdata.CumulativeTemporality || s.DataPoints[0].Value != 5 {
+		t.Errorf("inflight = %+v, want cumulative 5", s)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	v := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "tls")
+	if v == "" {
+		return 0, nil
+	}
+	if tv, ok := tlsVersions[v]; ok {
+		return tv, nil
+	}
+	return 0, fmt.Errorf("unknown tls version %q", version)
+}
+
+// tlsFileReloader 在握手时检查证书文件的修改时间，文件变化后自动重新加载
+type tlsFileReloader struct {
+	caFile   string
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	caModTime   time.Time
+	certModTime time.Time
+	keyModTime  time.Time
+	pool        *x509.CertPool
+	cert        *tls.Certificate
+}
+
+func modTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+func (r *tlsFileReloader) rootCAs() (*x509.CertPool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mt, err := modTime(r.caFile)
+	if err != nil {
+		return nil, err
+	}
+	if r.pool != nil && mt.Equal(r.caModTime) {
+		return r.pool, nil
+	}
+	pem, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid certificate found in ca file %q", r.caFile)
+	}
+	r.pool, r.caModTime = pool, mt
+	return r.pool, nil
+}
+
+func (r *tlsFileReloader) certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	certMT, err := modTime(r.certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyMT, err := modTime(r.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if r.cert != nil && certMT.Equal(r.certModTime) && keyMT.Equal(r.keyModTime) {
+		return r.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	r.cert, r.certModTime, r.keyModTime = &cert, certMT, keyMT
+	return r.cert, nil
+}
+
+// verifier 返回使用最新加载的CA校验服务端证书的VerifyConnection，serverName为实际连接的主机名或配置的ServerName，
+// IP地址按照证书的IP SAN校验，serverName为空时拒绝连接
+func (r *tlsFileReloader) verifier(serverName string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("no server certificate presented")
+		}
+		if serverName == "" {
+			return errors.New("no server name to verify the server certificate against, configure the tls server name")
+		}
+		pool, err := r.rootCAs()
+		if err != nil {
+			return err
+		}
+		opts := x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         pool,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err = cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+}
+
+// reloadingCredentials 握手时根据实际连接的地址设置VerifyConnection，
+// 没有配置ServerName时crypto/tls不会为IP地址发送SNI，ConnectionState.ServerName为空，不能用于校验
+type reloadingCredentials struct {
+	credentials.TransportCredentials
+	config   *tls.Config
+	reloader *tlsFileReloader
+}
+
+// ClientHandshake implements credentials.TransportCredentials
+func (c *reloadingCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	cfg := c.config.Clone()
+	serverName := cfg.ServerName
+	if serverName == "" {
+		serverName = authority
+		if host, _, err := net.SplitHostPort(authority); err == nil {
+			serverName = host
+		}
+	}
+	cfg.VerifyConnection = c.reloader.verifier(serverName)
+	return credentials.NewTLS(cfg).ClientHandshake(ctx, authority, rawConn)
+}
+
+// Clone implements credentials.TransportCredentials
+func (c *reloadingCredentials) Clone() credentials.TransportCredentials {
+	return &reloadingCredentials{TransportCredentials: c.TransportCredentials.Clone(), config: c.config.Clone(), reloader: c.reloader}
+}
+
+// OverrideServerName implements credentials.TransportCredentials
+func (c *reloadingCredentials) OverrideServerName(serverName string) error {
+	c.config.ServerName = serverName
+	return c.TransportCredentials.OverrideServerName(serverName)
+}
+
+func (c *Config) hasCustomTLS() bool {
+	return c.TLSCAFile != "" || c.TLSCertFile != "" || c.TLSKeyFile != "" || c.TLSServerName != "" || c.TLSMinVersion != ""
+}
+
+// newTLSConfig 根据配置生成TLS配置，CA和客户端证书在文件变化后自动重新加载
+func (c *Config) newTLSConfig() (*tls.Config, *tlsFileReloader, error) {
+	minVersion, err := parseTLSVersion(c.TLSMinVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := &tls.Config{
+		ServerName: c.TLSServerName,
+		MinVersion: minVersion,
+	}
+	r := &tlsFileReloader{caFile: c.TLSCAFile, certFile: c.TLSCertFile, keyFile: c.TLSKeyFile}
+	if c.TLSCAFile != "" {
+		if _, err := r.rootCAs(); err != nil {
+			return nil, nil, err
+		}
+		// 由 VerifyConnection 使用重新加载后的CA完成校验，GRPC握手时会替换为按实际连接地址校验的函数
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = r.verifier(c.TLSServerName)
+	}
+	if c.TLSCertFile != "" {
+		if _, err := r.certificate(); err != nil {
+			return nil, nil, err
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		}
+	}
+	return cfg, r, nil
+}
+
+// transportCredentials 返回Trace、Metric和Log共用的GRPC TLS凭证
+func (c *Config) transportCredentials() (credentials.TransportCredentials, error) {
+	if !c.hasCustomTLS() {
+		return credentials.NewClientTLSFromCert(nil, ""), nil
+	}
+	cfg, r, err := c.newTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if c.TLSCAFile == "" {
+		return credentials.NewTLS(cfg), nil
+	}
+	return &reloadingCredentials{TransportCredentials: credentials.NewTLS(cfg), config: cfg, reloader: r}, nil
+}
+
+func (c *Config) validateTLS() error {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("tls client certificate and key must be configured together")
+	}
+	_, err := parseTLSVersion(c.TLSMinVersion)
+	return err
+}
@@ -0,0 +1,183 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA 生成测试用的CA，并签发带有指定DNS和IP SAN的服务端证书
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) writePEM(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func (ca *testCA) issue(t *testing.T, dnsNames []string, ips []net.IP) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshake 使用配置生成的GRPC凭证连接一个使用cert的TLS服务端
+func handshake(t *testing.T, c *Config, cert tls.Certificate) error {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.(*tls.Conn).Handshake()
+	}()
+
+	creds, err := c.transportCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rawConn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := creds.ClientHandshake(ctx, ln.Addr().String(), rawConn)
+	if err == nil {
+		conn.Close()
+	}
+	return err
+}
+
+func TestTLSVerifyDialedHost(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := ca.writePEM(t)
+	loopback := []net.IP{net.ParseIP("127.0.0.1")}
+
+	tests := []struct {
+		name       string
+		serverName string
+		cert       tls.Certificate
+		wantErr    bool
+	}{
+		{"ip san matches dialed ip", "", ca.issue(t, nil, loopback), false},
+		{"dns san does not match dialed ip", "", ca.issue(t, []string{"collector.example.com"}, nil), true},
+		{"configured server name matches", "collector.example.com", ca.issue(t, []string{"collector.example.com"}, nil), false},
+		{"configured server name does not match", "other.example.com", ca.issue(t, []string{"collector.example.com"}, loopback), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{TLSCAFile: caFile, TLSServerName: tt.serverName}
+			err := handshake(t, c, tt.cert)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("handshake error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSVerifyUntrustedCA(t *testing.T) {
+	trusted, untrusted := newTestCA(t), newTestCA(t)
+	c := &Config{TLSCAFile: trusted.writePEM(t)}
+	if err := handshake(t, c, untrusted.issue(t, nil, []net.IP{net.ParseIP("127.0.0.1")})); err == nil {
+		t.Error("handshake with a certificate from an untrusted ca should fail")
+	}
+}
+
+func TestTLSVerifierRequiresServerName(t *testing.T) {
+	ca := newTestCA(t)
+	r := &tlsFileReloader{caFile: ca.writePEM(t)}
+	cert := ca.issue(t, []string{"collector.example.com"}, nil)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+	if err := r.verifier("")(cs); err == nil {
+		t.Error("verification without a server name should fail")
+	}
+	if err := r.verifier("collector.example.com")(cs); err != nil {
+		t.Errorf("verification with a matching server name failed: %v", err)
+	}
+}
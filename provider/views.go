@@ -0,0 +1,234 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// aggregation names of MetricView
+const (
+	AggregationDefault                         = "default"
+	AggregationDrop                            = "drop"
+	AggregationSum                             = "sum"
+	AggregationLastValue                       = "last_value"
+	AggregationExplicitBucketHistogram         = "explicit_bucket_histogram"
+	AggregationBase2ExponentialBucketHistogram = "base2_exponential_bucket_histogram"
+)
+
+const (
+	defaultExponentialMaxSize  = 160
+	defaultExponentialMaxScale = 20
+)
+
+var instrumentKinds = map[string]metric.InstrumentKind{
+	"counter":                    metric.InstrumentKindCounter,
+	"up_down_counter":            metric.InstrumentKindUpDownCounter,
+	"histogram":                  metric.InstrumentKindHistogram,
+	"gauge":                      metric.InstrumentKindGauge,
+	"observable_counter":         metric.InstrumentKindObservableCounter,
+	"observable_up_down_counter": metric.InstrumentKindObservableUpDownCounter,
+	"observable_gauge":           metric.InstrumentKindObservableGauge,
+}
+
+// MetricView selects instruments and changes the metric stream they produce,
+// see metric.NewView for the matching rules
+// 指标视图配置，用于重命名指标、过滤属性和修改聚合方式
+type MetricView struct {
+	// 匹配条件，InstrumentName支持*和?通配符，为空的条件不参与匹配
+	InstrumentName string `json:"instrument_name"`
+	InstrumentKind string `json:"instrument_kind"`
+	MeterName      string `json:"meter_name"`
+	MeterVersion   string `json:"meter_version"`
+
+	// 修改后的指标，为空时保持不变，InstrumentName包含通配符时不能修改Name
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// 属性白名单和黑名单，只能设置其中一个
+	AttributeAllowList []string `json:"attribute_allow_list"`
+	AttributeDenyList  []string `json:"attribute_deny_list"`
+	// 聚合方式: default, drop, sum, last_value, explicit_bucket_histogram, base2_exponential_bucket_histogram
+	Aggregation string `json:"aggregation"`
+	// explicit_bucket_histogram的桶边界，需要严格递增，设置后Aggregation可以为空
+	Boundaries []float64 `json:"boundaries"`
+	// base2_exponential_bucket_histogram的最大桶数和最大精度，为0时使用默认值160和20
+	MaxSize  int32 `json:"max_size"`
+	MaxScale int32 `json:"max_scale"`
+}
+
+func (v MetricView) validate() error {
+	if v.InstrumentName == "" && v.InstrumentKind == "" && v.MeterName == "" {
+		return errors.New("instrument_name, instrument_kind or meter_name is required")
+	}
+	if v.InstrumentKind != "" {
+		if _, ok := instrumentKinds[v.InstrumentKind]; !ok {
+			return fmt.Errorf("unknown instrument kind %q", v.InstrumentKind)
+		}
+	}
+	if v.Name != "" && (v.InstrumentName == "" || strings.ContainsAny(v.InstrumentName, "*?")) {
+		return errors.New("name can only be set when instrument_name matches a single instrument")
+	}
+	if len(v.AttributeAllowList) > 0 && len(v.AttributeDenyList) > 0 {
+		return errors.New("attribute_allow_list and attribute_deny_list cannot be used together")
+	}
+	if len(v.Boundaries) > 0 && v.Aggregation != "" && v.Aggregation != AggregationExplicitBucketHistogram {
+		return fmt.Errorf("boundaries cannot be used with aggregation %q", v.Aggregation)
+	}
+	if (v.MaxSize != 0 || v.MaxScale != 0) && v.Aggregation != AggregationBase2ExponentialBucketHistogram {
+		return fmt.Errorf("max_size and max_scale cannot be used with aggregation %q", v.Aggregation)
+	}
+	if _, err := v.aggregation(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v MetricView) aggregation() (metric.Aggregation, error) {
+	if v.Aggregation == "" && len(v.Boundaries) > 0 {
+		v.Aggregation = AggregationExplicitBucketHistogram
+	}
+	switch v.Aggregation {
+	case "":
+		return nil, nil
+	case AggregationDefault:
+		return metric.AggregationDefault{}, nil
+	case AggregationDrop:
+		return metric.AggregationDrop{}, nil
+	case AggregationSum:
+		return metric.AggregationSum{}, nil
+	case AggregationLastValue:
+		return metric.AggregationLastValue{}, nil
+	case AggregationExplicitBucketHistogram:
+		if len(v.Boundaries) == 0 {
+			return metric.DefaultAggregationSelector(metric.InstrumentKindHistogram), nil
+		}
+		if err := validateBoundaries(v.Boundaries); err != nil {
+			return nil, err
+		}
+		return metric.AggregationExplicitBucketHistogram{Boundaries: v.Boundaries}, nil
+	case AggregationBase2ExponentialBucketHistogram:
+		return exponentialHistogram(v.MaxSize, v.MaxScale)
+	}
+	return nil, fmt.Errorf("unknown aggregation %q", v.Aggregation)
+}
+
+// view 转换为metric.View，调用前需要先通过validate校验
+func (v MetricView) view() metric.View {
+	criteria := metric.Instrument{
+		Name:  v.InstrumentName,
+		Kind:  instrumentKinds[v.InstrumentKind],
+		Scope: instrumentation.Scope{Name: v.MeterName, Version: v.MeterVersion},
+	}
+	mask := metric.Stream{Name: v.Name, Description: v.Description}
+	mask.Aggregation, _ = v.aggregation()
+	if len(v.AttributeAllowList) > 0 {
+		mask.AttributeFilter = attribute.NewAllowKeysFilter(attributeKeys(v.AttributeAllowList)...)
+	}
+	if len(v.AttributeDenyList) > 0 {
+		mask.AttributeFilter = attribute.NewDenyKeysFilter(attributeKeys(v.AttributeDenyList)...)
+	}
+	return metric.NewView(criteria, mask)
+}
+
+func validateBoundaries(boundaries []float64) error {
+	for i := 1; i < len(boundaries); i++ {
+		if boundaries[i] <= boundaries[i-1] {
+			return fmt.Errorf("histogram boundaries must be strictly increasing: %v", boundaries)
+		}
+	}
+	return nil
+}
+
+// exponentialHistogram 返回指数直方图聚合，maxSize和maxScale为0时使用默认值
+func exponentialHistogram(maxSize, maxScale int32) (metric.Aggregation, error) {
+	if maxSize == 0 {
+		maxSize = defaultExponentialMaxSize
+	}
+	if maxScale == 0 {
+		maxScale = defaultExponentialMaxScale
+	}
+	if maxSize < 2 {
+		return nil, fmt.Errorf("exponential histogram max size must be at least 2, got %d", maxSize)
+	}
+	if maxScale < -10 || maxScale > 20 {
+		return nil, fmt.Errorf("exponential histogram max scale must be in [-10, 20], got %d", maxScale)
+	}
+	return metric.AggregationBase2ExponentialHistogram{MaxSize: maxSize, MaxScale: maxScale}, nil
+}
+
+// aggregationSelector 返回各类指标默认的聚合方式，配置为指数直方图时替换Histogram的默认聚合
+func (c *Config) aggregationSelector(kind metric.InstrumentKind) metric.Aggregation {
+	if kind == metric.InstrumentKindHistogram && c.MetricHistogramAggregation == AggregationBase2ExponentialBucketHistogram {
+		if agg, err := exponentialHistogram(c.MetricHistogramMaxSize, c.MetricHistogramMaxScale); err == nil {
+			return agg
+		}
+	}
+	return metric.DefaultAggregationSelector(kind)
+}
+
+func attributeKeys(keys []string) []attribute.Key {
+	result := make([]attribute.Key, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, attribute.Key(key))
+	}
+	return result
+}
+
+// loadMetricViews 从JSON文件中读取视图配置，文件内容为MetricView数组
+func loadMetricViews(path string) ([]MetricView, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var views []MetricView
+	if err := json.Unmarshal(b, &views); err != nil {
+		return nil, fmt.Errorf("parse metric views file %s: %w", path, err)
+	}
+	return views, nil
+}
+
+func (c *Config) validateMetricViews() error {
+	switch c.MetricHistogramAggregation {
+	case AggregationExplicitBucketHistogram:
+	case AggregationBase2ExponentialBucketHistogram:
+		if _, err := exponentialHistogram(c.MetricHistogramMaxSize, c.MetricHistogramMaxScale); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown histogram aggregation %q", c.MetricHistogramAggregation)
+	}
+	for i, v := range c.MetricViews {
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("invalid metric view #%d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) metricViews() []metric.View {
+	views := make([]metric.View, 0, len(c.MetricViews))
+	for _, v := range c.MetricViews {
+		views = append(views, v.view())
+	}
+	return views
+}
@@ -0,0 +1,305 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectWithViews 使用views创建MeterProvider，调用record记录数据后返回按名称索引的指标
+func collectWithViews(t *testing.T, record func(otelmetric.Meter), views ...metric.View) map[string]metricdata.Metrics {
+	t.Helper()
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader), metric.WithView(views...))
+	defer meterProvider.Shutdown(context.Background())
+	record(meterProvider.Meter("app", otelmetric.WithInstrumentationVersion("v1")))
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	metrics := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+	return metrics
+}
+
+func TestMetricViewValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		view    MetricView
+		wantErr string
+	}{
+		{name: "instrument name", view: MetricView{InstrumentName: "requests"}},
+		{name: "meter name", view: MetricView{MeterName: "app", Aggregation: AggregationDrop}},
+		{name: "instrument kind", view: MetricView{InstrumentKind: "observable_gauge", AttributeDenyList: []string{"pid"}}},
+		{name: "rename", view: MetricView{InstrumentName: "requests", Name: "http.requests"}},
+		{name: "boundaries without aggregation", view: MetricView{InstrumentName: "latency", Boundaries: []float64{1, 10}}},
+		{name: "no criteria", view: MetricView{Name: "x"}, wantErr: "is required"},
+		{name: "unknown kind", view: MetricView{InstrumentKind: "timer"}, wantErr: "unknown instrument kind"},
+		{name: "rename wildcard", view: MetricView{InstrumentName: "http.*", Name: "x"}, wantErr: "single instrument"},
+		{name: "rename by kind", view: MetricView{InstrumentKind: "counter", Name: "x"}, wantErr: "single instrument"},
+		{name: "allow and deny", view: MetricView{InstrumentName: "requests", AttributeAllowList: []string{"a"}, AttributeDenyList: []string{"b"}}, wantErr: "cannot be used together"},
+		{name: "boundaries with sum", view: MetricView{InstrumentName: "latency", Aggregation: AggregationSum, Boundaries: []float64{1}}, wantErr: "boundaries cannot be used"},
+		{name: "max size without exponential", view: MetricView{InstrumentName: "latency", MaxSize: 10}, wantErr: "max_size and max_scale"},
+		{name: "unknown aggregation", view: MetricView{InstrumentName: "latency", Aggregation: "median"}, wantErr: "unknown aggregation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.view.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetricView(t *testing.T) {
+	record := func(meter otelmetric.Meter) {
+		requests, _ := meter.Int64Counter("requests")
+		requests.Add(context.Background(), 1, otelmetric.WithAttributes(attribute.String("path", "/users"), attribute.Int("pid", 1)))
+		debug, _ := meter.Int64Counter("debug.calls")
+		debug.Add(context.Background(), 1)
+		queue, _ := meter.Int64UpDownCounter("queue.size")
+		queue.Add(context.Background(), 3, otelmetric.WithAttributes(attribute.String("queue", "a"), attribute.Int("pid", 1)))
+	}
+	views := []MetricView{
+		{InstrumentName: "requests", Name: "http.requests", Description: "HTTP requests", AttributeAllowList: []string{"path"}},
+		{InstrumentName: "debug.*", Aggregation: AggregationDrop},
+		{InstrumentKind: "up_down_counter", MeterName: "app", MeterVersion: "v1", AttributeDenyList: []string{"pid"}},
+		// 不匹配的Meter版本不生效
+		{MeterName: "app", MeterVersion: "v2", Aggregation: AggregationDrop},
+	}
+	var metricViews []metric.View
+	for _, v := range views {
+		if err := v.validate(); err != nil {
+			t.Fatal(err)
+		}
+		metricViews = append(metricViews, v.view())
+	}
+	metrics := collectWithViews(t, record, metricViews...)
+
+	if _, ok := metrics["requests"]; ok {
+		t.Error("renamed metric is still exported with the original name")
+	}
+	requests, ok := metrics["http.requests"]
+	if !ok || requests.Description != "HTTP requests" {
+		t.Fatalf("renamed metric not found: %+v", requests)
+	}
+	if attrs := requests.Data.(metricdata.Sum[int64]).DataPoints[0].Attributes; attrs != attribute.NewSet(attribute.String("path", "/users")) {
+		t.Errorf("attributes = %v, want only path", attrs.ToSlice())
+	}
+	if _, ok := metrics["debug.calls"]; ok {
+		t.Error("dropped metric is exported")
+	}
+	queue, ok := metrics["queue.size"]
+	if !ok {
+		t.Fatal("queue.size not exported")
+	}
+	if attrs := queue.Data.(metricdata.Sum[int64]).DataPoints[0].Attributes; attrs != attribute.NewSet(attribute.String("queue", "a")) {
+		t.Errorf("attributes = %v, want pid removed", attrs.ToSlice())
+	}
+}
+
+func TestLoadMetricViews(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "views.json")
+	content := `[
+		{"instrument_name": "requests", "name": "http.requests", "attribute_allow_list": ["path"]},
+		{"instrument_name": "debug.*", "aggregation": "drop"}
+	]`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	views, err := loadMetricViews(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 2 || views[0].Name != "http.requests" || views[0].AttributeAllowList[0] != "path" || views[1].Aggregation != AggregationDrop {
+		t.Fatalf("unexpected views %+v", views)
+	}
+
+	// 文件中的视图在Option配置的视图之前
+	t.Setenv("SLS_OTEL_METRIC_VIEWS_FILE", path)
+	c, err := NewConfig(WithServiceName("test"), WithMetricViews(MetricView{InstrumentName: "latency", Aggregation: AggregationDrop}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.MetricViews) != 3 || c.MetricViews[0].InstrumentName != "requests" || c.MetricViews[2].InstrumentName != "latency" {
+		t.Fatalf("unexpected views %+v", c.MetricViews)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"instrument_name": "requests"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadMetricViews(invalid); err == nil || !strings.Contains(err.Error(), invalid) {
+		t.Errorf("loadMetricViews of a non-array file = %v", err)
+	}
+	if _, err := loadMetricViews(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loadMetricViews of a missing file should fail")
+	}
+
+	// 文件中无效的视图在NewConfig时报告
+	if err := os.WriteFile(invalid, []byte(`[{"instrument_name": "requests"}, {"name": "x"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SLS_OTEL_METRIC_VIEWS_FILE", invalid)
+	if _, err := NewConfig(WithServiceName("test")); err == nil || !strings.Contains(err.Error(), "#1") {
+		t.Errorf("NewConfig with an invalid view = %v", err)
+	}
+}
+
+// recordLatency 记录latency直方图
+func recordLatency(values ...float64) func(otelmetric.Meter) {
+	return func(meter otelmetric.Meter) {
+		latency, _ := meter.Float64Histogram("latency")
+		for _, v := range values {
+			latency.Record(context.Background(), v)
+		}
+	}
+}
+
+func TestMetricViewBoundaries(t *testing.T) {
+	view := MetricView{InstrumentName: "latency", Boundaries: []float64{1, 5, 10}}
+	if err := view.validate(); err != nil {
+		t.Fatal(err)
+	}
+	metrics := collectWithViews(t, recordLatency(0.5, 3, 4, 20), view.view())
+	dp := metrics["latency"].Data.(metricdata.Histogram[float64]).DataPoints[0]
+	if !slices.Equal(dp.Bounds, []float64{1, 5, 10}) || !slices.Equal(dp.BucketCounts, []uint64{1, 2, 0, 1}) {
+		t.Fatalf("bounds = %v, counts = %v", dp.Bounds, dp.BucketCounts)
+	}
+
+	// 未设置桶边界时使用SDK默认的边界
+	view = MetricView{InstrumentName: "latency", Aggregation: AggregationExplicitBucketHistogram}
+	metrics = collectWithViews(t, recordLatency(3), view.view())
+	want := metric.DefaultAggregationSelector(metric.InstrumentKindHistogram).(metric.AggregationExplicitBucketHistogram).Boundaries
+	if dp := metrics["latency"].Data.(metricdata.Histogram[float64]).DataPoints[0]; !slices.Equal(dp.Bounds, want) {
+		t.Fatalf("bounds = %v, want default %v", dp.Bounds, want)
+	}
+
+	for _, boundaries := range [][]float64{{1, 1}, {10, 5}} {
+		if err := (MetricView{InstrumentName: "latency", Boundaries: boundaries}).validate(); err == nil {
+			t.Errorf("boundaries %v should be invalid", boundaries)
+		}
+	}
+}
+
+func TestMetricViewExponentialHistogram(t *testing.T) {
+	view := MetricView{InstrumentName: "latency", Aggregation: AggregationBase2ExponentialBucketHistogram, MaxSize: 4, MaxScale: 5}
+	if err := view.validate(); err != nil {
+		t.Fatal(err)
+	}
+	metrics := collectWithViews(t, recordLatency(1, 2, 100, 1000), view.view())
+	data, ok := metrics["latency"].Data.(metricdata.ExponentialHistogram[float64])
+	if !ok {
+		t.Fatalf("latency data is %T, want exponential histogram", metrics["latency"].Data)
+	}
+	dp := data.DataPoints[0]
+	if dp.Count != 4 || dp.Scale > 5 || len(dp.PositiveBucket.Counts) > 4 {
+		t.Fatalf("count = %d, scale = %d, buckets = %v", dp.Count, dp.Scale, dp.PositiveBucket.Counts)
+	}
+
+	tests := []struct {
+		maxSize, maxScale int32
+		want              metric.Aggregation
+	}{
+		{0, 0, metric.AggregationBase2ExponentialHistogram{MaxSize: 160, MaxScale: 20}},
+		{8, -10, metric.AggregationBase2ExponentialHistogram{MaxSize: 8, MaxScale: -10}},
+		{1, 0, nil},
+		{0, 21, nil},
+		{0, -11, nil},
+	}
+	for _, tt := range tests {
+		v := MetricView{InstrumentName: "latency", Aggregation: AggregationBase2ExponentialBucketHistogram, MaxSize: tt.maxSize, MaxScale: tt.maxScale}
+		got, err := v.aggregation()
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("max size %d, max scale %d should be invalid", tt.maxSize, tt.maxScale)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("aggregation(%d, %d) = %v, %v, want %v", tt.maxSize, tt.maxScale, got, err, tt.want)
+		}
+	}
+}
+
+func TestExponentialHistogramByDefault(t *testing.T) {
+	c, err := NewConfig(WithServiceName("test"), WithExponentialHistogram(8, 10),
+		WithMetricViews(MetricView{InstrumentName: "fixed", Boundaries: []float64{1, 10}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg := c.aggregationSelector(metric.InstrumentKindHistogram); agg != (metric.AggregationBase2ExponentialHistogram{MaxSize: 8, MaxScale: 10}) {
+		t.Fatalf("histogram aggregation = %v", agg)
+	}
+	if agg := c.aggregationSelector(metric.InstrumentKindCounter); agg != (metric.AggregationSum{}) {
+		t.Fatalf("counter aggregation = %v", agg)
+	}
+
+	reader := metric.NewManualReader(metric.WithAggregationSelector(c.aggregationSelector))
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader), metric.WithView(c.metricViews()...))
+	defer meterProvider.Shutdown(context.Background())
+	meter := meterProvider.Meter("app")
+	latency, _ := meter.Float64Histogram("latency")
+	latency.Record(context.Background(), 3)
+	fixed, _ := meter.Float64Histogram("fixed")
+	fixed.Record(context.Background(), 3)
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch m.Name {
+		case "latency":
+			if _, ok := m.Data.(metricdata.ExponentialHistogram[float64]); !ok {
+				t.Errorf("latency data is %T, want exponential histogram", m.Data)
+			}
+		case "fixed":
+			// 视图中的桶边界优先于默认的指数直方图
+			if data, ok := m.Data.(metricdata.Histogram[float64]); !ok || !slices.Equal(data.DataPoints[0].Bounds, []float64{1, 10}) {
+				t.Errorf("fixed data is %+v, want explicit bucket histogram", m.Data)
+			}
+		}
+	}
+
+	if _, err := NewConfig(WithServiceName("test"), WithExponentialHistogram(1, 0)); err == nil {
+		t.Error("exponential histogram with max size 1 should be invalid")
+	}
+	t.Setenv("SLS_OTEL_METRIC_HISTOGRAM_AGGREGATION", "summary")
+	if _, err := NewConfig(WithServiceName("test")); err == nil {
+		t.Error("unknown histogram aggregation should be invalid")
+	}
+}
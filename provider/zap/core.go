@@ -0,0 +1,251 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zap correlates zap log entries with traces: the Core adds trace_id, span_id and trace_flags of
+// the span in the context passed with Context to every entry, and optionally forwards entries to the
+// OpenTelemetry Logs pipeline created by provider.Start, call provider.Shutdown in a zap.WithFatalHook
+// so that fatal entries are exported before the process exits
+// 为zap日志添加trace_id、span_id和trace_flags，并可以把日志转发到provider创建的OpenTelemetry Logs，
+// 通过zap.WithFatalHook调用provider.Shutdown，确保进程退出前Fatal日志已经发送
+package zap
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider"
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/internal/logattr"
+)
+
+// ScopeName is the instrumentation scope name of forwarded entries
+const ScopeName = "github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/zap"
+
+// field names of the trace context
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// contextKey 携带context.Context的字段名，字段类型为SkipType，其他Core不会输出该字段
+const contextKey = "otel.context"
+
+// Context returns a field carrying ctx, the Core reads the span context and trace fields from it,
+// for example logger.Info("msg", zap.Context(ctx)) or logger.With(zap.Context(ctx))
+// 返回携带ctx的字段，Core从中读取当前Span，例如 logger.Info("msg", zap.Context(ctx))
+func Context(ctx context.Context) zapcore.Field {
+	return zapcore.Field{Key: contextKey, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// Option configures the Core
+type Option func(*Core)
+
+// WithLoggerProvider forwards entries to the OpenTelemetry Logs pipeline of provider, usually config.LoggerProvider()
+// after provider.Start, entries are not forwarded when provider is nil
+// 把日志转发到OpenTelemetry Logs，通常传入provider.Start之后的config.LoggerProvider()，为nil时不转发
+func WithLoggerProvider(provider log.LoggerProvider) Option {
+	return func(c *Core) {
+		if provider != nil {
+			c.logger = provider.Logger(ScopeName)
+		}
+	}
+}
+
+// WithLevel configures the minimum level of entries forwarded to OpenTelemetry Logs, defaults to zapcore.InfoLevel
+// 配置转发到OpenTelemetry Logs的最低日志级别，默认为zapcore.InfoLevel
+func WithLevel(level zapcore.LevelEnabler) Option {
+	return func(c *Core) {
+		c.level = level
+	}
+}
+
+// WithRedactor redacts the message and attributes of forwarded entries, usually the redactor returned by config.Redactor(),
+// which is nil when redaction is not enabled, entries written to the wrapped core are not redacted
+// 脱敏转发到OpenTelemetry Logs的日志内容和属性，通常传入config.Redactor()，未开启脱敏时为nil
+func WithRedactor(redactor *provider.Redactor) Option {
+	return func(c *Core) {
+		c.redactor = redactor
+	}
+}
+
+// Core wraps a zapcore.Core, adding the trace context to entries and forwarding them to OpenTelemetry Logs
+// 包装zapcore.Core，在日志中添加Trace上下文并转发到OpenTelemetry Logs
+type Core struct {
+	next     zapcore.Core
+	logger   log.Logger
+	level    zapcore.LevelEnabler
+	redactor *provider.Redactor
+
+	// 通过With添加的context和转发到OpenTelemetry Logs时使用的属性
+	ctx   context.Context
+	attrs []log.KeyValue
+}
+
+var _ zapcore.Core = (*Core)(nil)
+
+// NewCore creates a Core, next may be nil when entries are only forwarded to OpenTelemetry Logs
+// 创建Core，只转发到OpenTelemetry Logs时next可以为nil
+func NewCore(next zapcore.Core, opts ...Option) *Core {
+	c := &Core{next: next, level: zapcore.InfoLevel, ctx: context.Background()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Enabled implements zapcore.LevelEnabler
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return (c.next != nil && c.next.Enabled(level)) || c.forward(level)
+}
+
+// With implements zapcore.Core
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	ctx, fields := extractContext(fields)
+	if ctx != nil {
+		clone.ctx = ctx
+	}
+	if c.next != nil {
+		clone.next = c.next.With(fields)
+	}
+	if c.logger != nil {
+		clone.attrs = append(c.attrs[:len(c.attrs):len(c.attrs)], convertFields(fields)...)
+	}
+	return &clone
+}
+
+// Check implements zapcore.Core
+func (c *Core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write implements zapcore.Core, trace fields are added only when the context contains a valid span context
+func (c *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	ctx, fields := extractContext(fields)
+	if ctx == nil {
+		ctx = c.ctx
+	}
+	if c.forward(entry.Level) {
+		c.logger.Emit(ctx, c.convertEntry(entry, fields))
+	}
+	if c.next == nil || !c.next.Enabled(entry.Level) {
+		return nil
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields[:len(fields):len(fields)],
+			zapcore.Field{Key: TraceIDKey, Type: zapcore.StringType, String: sc.TraceID().String()},
+			zapcore.Field{Key: SpanIDKey, Type: zapcore.StringType, String: sc.SpanID().String()},
+			zapcore.Field{Key: TraceFlagsKey, Type: zapcore.StringType, String: sc.TraceFlags().String()},
+		)
+	}
+	return c.next.Write(entry, fields)
+}
+
+// Sync implements zapcore.Core
+func (c *Core) Sync() error {
+	if c.next == nil {
+		return nil
+	}
+	return c.next.Sync()
+}
+
+// forward 是否转发到OpenTelemetry Logs
+func (c *Core) forward(level zapcore.Level) bool {
+	return c.logger != nil && c.level.Enabled(level)
+}
+
+func (c *Core) convertEntry(entry zapcore.Entry, fields []zapcore.Field) log.Record {
+	var record log.Record
+	record.SetTimestamp(entry.Time)
+	message, kvs := entry.Message, append(c.attrs[:len(c.attrs):len(c.attrs)], convertFields(fields)...)
+	if c.redactor != nil {
+		message, kvs = c.redactor.RedactString(message), c.redactor.RedactLogKeyValues(kvs)
+	}
+	record.SetBody(log.StringValue(message))
+	record.SetSeverity(severity(entry.Level))
+	record.SetSeverityText(entry.Level.CapitalString())
+	record.AddAttributes(kvs...)
+	if entry.LoggerName != "" {
+		record.AddAttributes(log.String("logger", entry.LoggerName))
+	}
+	if entry.Caller.Defined {
+		record.AddAttributes(logattr.Caller(entry.Caller.File, entry.Caller.Line, entry.Caller.Function)...)
+	}
+	if entry.Stack != "" {
+		record.AddAttributes(log.String("code.stacktrace", entry.Stack))
+	}
+	return record
+}
+
+// severity 把zap的日志级别转换为OpenTelemetry的日志级别
+func severity(level zapcore.Level) log.Severity {
+	switch level {
+	case zapcore.DebugLevel:
+		return log.SeverityDebug
+	case zapcore.InfoLevel:
+		return log.SeverityInfo
+	case zapcore.WarnLevel:
+		return log.SeverityWarn
+	case zapcore.ErrorLevel:
+		return log.SeverityError
+	case zapcore.DPanicLevel:
+		return log.SeverityFatal1
+	case zapcore.PanicLevel:
+		return log.SeverityFatal2
+	case zapcore.FatalLevel:
+		return log.SeverityFatal3
+	}
+	return log.SeverityUndefined
+}
+
+// extractContext 取出Context字段，返回的字段列表中不再包含该字段
+func extractContext(fields []zapcore.Field) (context.Context, []zapcore.Field) {
+	var ctx context.Context
+	filtered := fields[:0:0]
+	for i, f := range fields {
+		if f.Key == contextKey && f.Type == zapcore.SkipType {
+			if fieldCtx, ok := f.Interface.(context.Context); ok {
+				if ctx == nil {
+					filtered = append(filtered, fields[:i]...)
+				}
+				ctx = fieldCtx
+				continue
+			}
+		}
+		if ctx != nil {
+			filtered = append(filtered, f)
+		}
+	}
+	if ctx == nil {
+		return nil, fields
+	}
+	return ctx, filtered
+}
+
+// convertFields 使用MapObjectEncoder把zap字段转换为属性，保持字段顺序
+func convertFields(fields []zapcore.Field) []log.KeyValue {
+	kvs := make([]log.KeyValue, 0, len(fields))
+	for _, f := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		kvs = append(kvs, logattr.KeyValues(enc.Fields)...)
+	}
+	return kvs
+}
@@ -0,0 +1,152 @@
+// Copyright The AliyunSLS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/log"
+	gozap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider"
+	"github.com/aliyun-sls/opentelemetry-go-provider-sls/provider/internal/logtest"
+)
+
+func TestCoreAddsTraceContext(t *testing.T) {
+	next, logs := observer.New(zapcore.InfoLevel)
+	logger := gozap.New(NewCore(next))
+	ctx, sc := logtest.ContextWithSpan()
+
+	logger.Info("with field", Context(ctx), gozap.String("path", "/users"))
+	logger.With(Context(ctx)).Info("with logger")
+	logger.Info("no span")
+	logger.Debug("filtered", Context(ctx))
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("logged %d entries, want 3", len(entries))
+	}
+	for _, e := range entries[:2] {
+		fields := e.ContextMap()
+		if fields[TraceIDKey] != sc.TraceID().String() || fields[SpanIDKey] != sc.SpanID().String() ||
+			fields[TraceFlagsKey] != sc.TraceFlags().String() {
+			t.Fatalf("entry %q missing trace fields: %v", e.Message, fields)
+		}
+		if _, ok := fields[contextKey]; ok {
+			t.Fatalf("entry %q contains the context field", e.Message)
+		}
+	}
+	if entries[0].ContextMap()["path"] != "/users" {
+		t.Fatalf("unexpected fields %v", entries[0].ContextMap())
+	}
+	if _, ok := entries[2].ContextMap()[TraceIDKey]; ok {
+		t.Fatal("trace fields added without span context")
+	}
+}
+
+func TestCoreForwardsEntries(t *testing.T) {
+	provider, exporter := logtest.NewLoggerProvider(t)
+	logger := gozap.New(NewCore(nil, WithLoggerProvider(provider)), gozap.AddCaller()).Named("api")
+	ctx, sc := logtest.ContextWithSpan()
+
+	logger = logger.With(gozap.String("service", "demo"))
+	logger.Debug("filtered")
+	logger.Warn("slow request", Context(ctx), gozap.Int("status", 200))
+
+	if len(exporter.Records()) != 1 {
+		t.Fatalf("exported %d records, want 1", len(exporter.Records()))
+	}
+	r := exporter.Records()[0]
+	if r.Body().AsString() != "slow request" || r.Severity() != log.SeverityWarn || r.SeverityText() != "WARN" {
+		t.Fatalf("body = %v, severity = %v, severity text = %q", r.Body(), r.Severity(), r.SeverityText())
+	}
+	if r.TraceID() != sc.TraceID() || r.SpanID() != sc.SpanID() {
+		t.Fatalf("record not correlated with span: %v %v", r.TraceID(), r.SpanID())
+	}
+	attrs := logtest.Attributes(r)
+	if attrs["service"].AsString() != "demo" || attrs["status"].AsInt64() != 200 || attrs["logger"].AsString() != "api" {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+	if attrs["code.lineno"].AsInt64() == 0 || attrs["code.filepath"].AsString() == "" {
+		t.Fatalf("missing caller attributes %v", attrs)
+	}
+	if _, ok := attrs[contextKey]; ok {
+		t.Fatal("context field forwarded as attribute")
+	}
+}
+
+func TestCoreLevel(t *testing.T) {
+	provider, exporter := logtest.NewLoggerProvider(t)
+	next, logs := observer.New(zapcore.DebugLevel)
+	core := NewCore(next, WithLoggerProvider(provider), WithLevel(zapcore.ErrorLevel))
+	if !core.Enabled(zapcore.DebugLevel) {
+		t.Fatal("Enabled should include the levels of next")
+	}
+	logger := gozap.New(core)
+	logger.Warn("warn")
+	logger.Error("error")
+	if len(exporter.Records()) != 1 || exporter.Records()[0].Severity() != log.SeverityError {
+		t.Fatalf("unexpected records %v", exporter.Records())
+	}
+	if logs.Len() != 2 {
+		t.Fatalf("logged %d entries to next, want 2", logs.Len())
+	}
+
+	core = NewCore(nil, WithLoggerProvider(nil))
+	if core.Enabled(zapcore.FatalLevel) {
+		t.Fatal("core without next and logger provider should be disabled")
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for level, want := range map[zapcore.Level]log.Severity{
+		zapcore.DebugLevel:  log.SeverityDebug,
+		zapcore.InfoLevel:   log.SeverityInfo,
+		zapcore.WarnLevel:   log.SeverityWarn,
+		zapcore.ErrorLevel:  log.SeverityError,
+		zapcore.DPanicLevel: log.SeverityFatal1,
+		zapcore.PanicLevel:  log.SeverityFatal2,
+		zapcore.FatalLevel:  log.SeverityFatal3,
+	} {
+		if got := severity(level); got != want {
+			t.Fatalf("severity(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestCoreRedactsForwardedEntries(t *testing.T) {
+	redactor, err := provider.NewRedactor([]string{"password"}, []string{provider.RedactionPatternCNMobile}, provider.RedactionModeMask, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggerProvider, exporter := logtest.NewLoggerProvider(t)
+	next, logs := observer.New(zapcore.InfoLevel)
+	logger := gozap.New(NewCore(next, WithLoggerProvider(loggerProvider), WithRedactor(redactor)))
+	logger.With(gozap.String("password", "secret")).Info("call +8613812345678", gozap.String("phone", "13812345678"))
+
+	r := exporter.Records()[0]
+	if got := r.Body().AsString(); got != "call ****" {
+		t.Fatalf("body = %q", got)
+	}
+	attrs := logtest.Attributes(r)
+	if attrs["password"].AsString() != "****" || attrs["phone"].AsString() != "****" {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+	if got := logs.All()[0].ContextMap()["phone"]; got != "13812345678" {
+		t.Fatalf("entry written to next core should not be redacted: %v", got)
+	}
+}